@@ -2,10 +2,12 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/umed-hotamov/golox/internal/ast"
 	"github.com/umed-hotamov/golox/internal/interpreter"
 	"github.com/umed-hotamov/golox/internal/lexer"
 	"github.com/umed-hotamov/golox/internal/parser"
@@ -34,12 +36,15 @@ func runFile(filename string) {
 	source := string(data)
 
 	interpreter := interpreter.NewInterpreter()
+	interpreter.SetModuleLoader(new(moduleLoader))
+	interpreter.SetScriptPath(filename)
 	run(source, interpreter)
 }
 
 func runPrompt() {
 	scanner := bufio.NewScanner(os.Stdin)
 	interpreter := interpreter.NewInterpreter()
+	interpreter.SetModuleLoader(new(moduleLoader))
 
 	for {
 		fmt.Print("golox~~>  ")
@@ -58,23 +63,48 @@ func runPrompt() {
 }
 
 func run(source string, interpreter *interpreter.Interpreter) {
+	statements, ok := compile(source, interpreter)
+	if !ok {
+		return
+	}
+
+	interpreter.Interpret(statements)
+}
+
+func compile(source string, interpreter *interpreter.Interpreter) ([]ast.Stmt, bool) {
 	lexer := lexer.NewLexer(source)
 	tokens := lexer.Lex()
 
 	parser := parser.NewParser(tokens)
 	statements := parser.Parse()
 	if lexer.HasError {
-		return
+		return nil, false
 	}
 	if parser.HasError {
-		return
+		return nil, false
 	}
 
 	resolver := resolver.NewResolver(interpreter)
 	resolver.Resolve(statements)
 	if resolver.HasError {
-		return
+		return nil, false
 	}
 
-	interpreter.Interpret(statements)
+	return statements, true
+}
+
+type moduleLoader struct{}
+
+func (m *moduleLoader) Load(interpreter *interpreter.Interpreter, path string) ([]ast.Stmt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, ok := compile(string(data), interpreter)
+	if !ok {
+		return nil, errors.New("compilation failed")
+	}
+
+	return statements, nil
 }
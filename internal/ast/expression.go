@@ -38,16 +38,123 @@ type Assign struct {
 	Value Expr
 }
 
+// Update reads Target (a variable, property or index), combines it with
+// Value using Operator and stores the result back. It covers compound
+// assignment and increment/decrement, whose Value is 1. Postfix updates
+// evaluate to the old value, all others to the new one.
+type Update struct {
+	Target   Expr
+	Operator lexer.Token
+	Value    Expr
+	Postfix  bool
+}
+
 type Logical struct {
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
 }
 
+type Conditional struct {
+	Condition Expr
+	Question  lexer.Token
+	Then      Expr
+	Else      Expr
+}
+
+// Range is a..b or a..<b, which excludes b. Step is nil unless given with
+// the 'step' keyword.
+type Range struct {
+	Start    Expr
+	Operator lexer.Token
+	End      Expr
+	Step     Expr
+}
+
+// Call is a call of Callee. Named arguments follow the positional ones, and
+// Names and NamedArguments are parallel. Optional marks a call written as
+// 'callee?.()', which skips the rest of its OptionalChain instead of calling
+// when the callee is nil.
 type Call struct {
-	Callee    Expr
-	Paren     lexer.Token
-	Arguments []Expr
+	Callee         Expr
+	Paren          lexer.Token
+	Arguments      []Expr
+	Names          []lexer.Token
+	NamedArguments []Expr
+	Optional       bool
+}
+
+// Optional marks a property access written as 'object?.name', which skips
+// the rest of its OptionalChain instead of failing when the object is nil.
+type Get struct {
+	Object   Expr
+	Name     lexer.Token
+	Optional bool
+}
+
+type Set struct {
+	Object Expr
+	Name   lexer.Token
+	Value  Expr
+}
+
+type This struct {
+	Keyword lexer.Token
+}
+
+type List struct {
+	Bracket  lexer.Token
+	Elements []Expr
+}
+
+type Map struct {
+	Brace  lexer.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+// Interpolation is a string literal with embedded expressions. Parts holds
+// the literal segments and the expressions in source order.
+type Interpolation struct {
+	Parts []Expr
+}
+
+// OptionalChain is a chain of calls, property accesses and subscripts with
+// at least one optional link. It yields nil when an optional link is skipped.
+type OptionalChain struct {
+	Expr Expr
+}
+
+type Index struct {
+	Object   Expr
+	Bracket  lexer.Token
+	Index    Expr
+	Optional bool
+}
+
+type SetIndex struct {
+	Object  Expr
+	Bracket lexer.Token
+	Index   Expr
+	Value   Expr
+}
+
+// Lambda is an anonymous function expression. The declaration's name is the
+// token that introduced it: either 'fun' or '=>'.
+type Lambda struct {
+	Declaration Function
+}
+
+// Await suspends the enclosing async function until the promise Value
+// evaluates to settles.
+type Await struct {
+	Keyword lexer.Token
+	Value   Expr
+}
+
+type Super struct {
+	Keyword lexer.Token
+	Method  lexer.Token
 }
 
 func (b Binary) Printer() string {
@@ -77,16 +184,130 @@ func (a Assign) Printer() string {
 	return fmt.Sprintf("(%v %v)", a.Value.Printer(), a.Name)
 }
 
+func (u Update) Printer() string {
+	if u.Postfix {
+		return fmt.Sprintf("(%v %v)", u.Target.Printer(), u.Operator.Lexeme)
+	}
+	return fmt.Sprintf("(%v %v %v)", u.Operator.Lexeme, u.Target.Printer(), u.Value.Printer())
+}
+
 func (l Logical) Printer() string {
 	return fmt.Sprintf("%v %v %v", l.Left.Printer(), l.Operator.Lexeme, l.Right.Printer())
 }
 
+func (c Conditional) Printer() string {
+	return fmt.Sprintf("(? %v %v %v)", c.Condition.Printer(), c.Then.Printer(), c.Else.Printer())
+}
+
+func (r Range) Printer() string {
+	if r.Step != nil {
+		return fmt.Sprintf("(%v %v %v step %v)", r.Operator.Lexeme, r.Start.Printer(), r.End.Printer(), r.Step.Printer())
+	}
+	return fmt.Sprintf("(%v %v %v)", r.Operator.Lexeme, r.Start.Printer(), r.End.Printer())
+}
+
 func (c Call) Printer() string {
 	s := fmt.Sprintf("%v", c.Callee.Printer())
+	if c.Optional {
+		s += "?."
+	}
 	s += "("
 	for _, a := range c.Arguments {
 		s += a.Printer()
 	}
+	for i, name := range c.Names {
+		s += name.Lexeme + ": " + c.NamedArguments[i].Printer()
+	}
+	s += ")"
+
+	return s
+}
+
+func (g Get) Printer() string {
+	if g.Optional {
+		return fmt.Sprintf("%v?.%v", g.Object.Printer(), g.Name.Lexeme)
+	}
+	return fmt.Sprintf("%v.%v", g.Object.Printer(), g.Name.Lexeme)
+}
+
+func (s Set) Printer() string {
+	return fmt.Sprintf("(%v.%v %v)", s.Object.Printer(), s.Name.Lexeme, s.Value.Printer())
+}
+
+func (t This) Printer() string {
+	return "this"
+}
+
+func (s Super) Printer() string {
+	return fmt.Sprintf("super.%v", s.Method.Lexeme)
+}
+
+func (l List) Printer() string {
+	s := "["
+	for i, e := range l.Elements {
+		if i > 0 {
+			s += ", "
+		}
+		s += e.Printer()
+	}
+	s += "]"
+
+	return s
+}
+
+func (m Map) Printer() string {
+	s := "{"
+	for i := range m.Keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += m.Keys[i].Printer() + ": " + m.Values[i].Printer()
+	}
+	s += "}"
+
+	return s
+}
+
+func (i Index) Printer() string {
+	if i.Optional {
+		return fmt.Sprintf("%v?.[%v]", i.Object.Printer(), i.Index.Printer())
+	}
+	return fmt.Sprintf("%v[%v]", i.Object.Printer(), i.Index.Printer())
+}
+
+func (o OptionalChain) Printer() string {
+	return o.Expr.Printer()
+}
+
+func (s SetIndex) Printer() string {
+	return fmt.Sprintf("(%v[%v] %v)", s.Object.Printer(), s.Index.Printer(), s.Value.Printer())
+}
+
+func (a Await) Printer() string {
+	return fmt.Sprintf("(await %v)", a.Value.Printer())
+}
+
+func (l Lambda) Printer() string {
+	s := "fun ("
+	if l.Declaration.Async {
+		s = "async " + s
+	}
+	for i, param := range l.Declaration.Params {
+		if i > 0 {
+			s += ", "
+		}
+		s += param.Lexeme
+	}
+	s += ")"
+
+	return s
+}
+
+func (i Interpolation) Printer() string {
+	s := "(interpolate"
+	for _, part := range i.Parts {
+		s += " " + part.Printer()
+	}
 	s += ")"
 
 	return s
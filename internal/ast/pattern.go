@@ -0,0 +1,57 @@
+package ast
+
+import "github.com/umed-hotamov/golox/internal/lexer"
+
+// Pattern is the left-hand side of a match arm. Patterns are tested against
+// a value and may bind parts of it to names scoped to the arm.
+type Pattern interface {
+	Ast
+}
+
+// LiteralPattern matches values equal to Value.
+type LiteralPattern struct {
+	Token lexer.Token
+	Value any
+}
+
+// BindingPattern matches any value and binds it to Name.
+type BindingPattern struct {
+	Name lexer.Token
+}
+
+// WildcardPattern, written '_', matches any value without binding it.
+type WildcardPattern struct {
+	Underscore lexer.Token
+}
+
+// ListPattern matches lists with exactly as many elements as it has, each
+// matching the corresponding element pattern.
+type ListPattern struct {
+	Bracket  lexer.Token
+	Elements []Pattern
+}
+
+func (l LiteralPattern) Printer() string {
+	return l.Token.Lexeme
+}
+
+func (b BindingPattern) Printer() string {
+	return b.Name.Lexeme
+}
+
+func (w WildcardPattern) Printer() string {
+	return "_"
+}
+
+func (l ListPattern) Printer() string {
+	s := "["
+	for i, e := range l.Elements {
+		if i > 0 {
+			s += ", "
+		}
+		s += e.Printer()
+	}
+	s += "]"
+
+	return s
+}
@@ -18,9 +18,12 @@ type Print struct {
 	Expression Expr
 }
 
+// Var declares a variable, or a constant when Const is set. Constants always
+// have an initializer.
 type Var struct {
 	Name        lexer.Token
 	Initializer Expr
+	Const       bool
 }
 
 type If struct {
@@ -33,15 +36,86 @@ type Block struct {
 	Statements []Stmt
 }
 
+// While also represents desugared for loops, whose increment runs after
+// every iteration, including ones cut short by continue.
 type While struct {
 	Condition Expr
 	Body      Stmt
+	Increment Expr
 }
 
+// Match runs the body of the first arm that matches Value.
+type Match struct {
+	Keyword lexer.Token
+	Value   Expr
+	Arms    []MatchArm
+}
+
+// MatchArm matches when any of its Patterns does and the optional Guard is
+// truthy. The default arm has no patterns and always matches.
+type MatchArm struct {
+	Keyword  lexer.Token
+	Patterns []Pattern
+	Guard    Expr
+	Body     Stmt
+}
+
+// ForIn runs Body once for every value Iterable produces, each time in a
+// fresh scope where Name is bound to that value.
+type ForIn struct {
+	Name     lexer.Token
+	In       lexer.Token
+	Iterable Expr
+	Body     Stmt
+}
+
+// Spawn runs Call on a goroutine of its own. The callee and the arguments
+// are evaluated before the goroutine starts.
+type Spawn struct {
+	Keyword lexer.Token
+	Call    Call
+}
+
+// Select waits until the channel operation of one of its arms can go ahead,
+// performs it and runs that arm's body. If there is a default arm, it runs
+// instead when no operation is ready right away.
+type Select struct {
+	Keyword lexer.Token
+	Arms    []SelectArm
+}
+
+// SelectArm sends Value on Channel, or receives from it when Value is nil.
+// Operation is the 'send' or 'receive' method name. A receive may declare
+// Name, scoped to Body, to hold the value. The default arm has no Channel.
+type SelectArm struct {
+	Keyword   lexer.Token
+	Name      *lexer.Token
+	Channel   Expr
+	Operation lexer.Token
+	Value     Expr
+	Body      Stmt
+}
+
+type Break struct {
+	Keyword lexer.Token
+}
+
+type Continue struct {
+	Keyword lexer.Token
+}
+
+// Function parameters may have default values: Defaults is as long as
+// Params and holds nil for parameters without one. Rest, when set, names the
+// list that collects arguments beyond Params. Functions whose body yields are
+// generators. Async functions return a promise of their result and may await.
 type Function struct {
-	Name   lexer.Token
-	Params []lexer.Token
-	Body   Block
+	Name      lexer.Token
+	Params    []lexer.Token
+	Defaults  []Expr
+	Rest      *lexer.Token
+	Body      Block
+	Generator bool
+	Async     bool
 }
 
 type Return struct {
@@ -49,9 +123,37 @@ type Return struct {
 	Value   Expr
 }
 
-type Class struct {
+type Yield struct {
+	Keyword lexer.Token
+	Value   Expr
+}
+
+type Throw struct {
+	Keyword lexer.Token
+	Value   Expr
+}
+
+// Try has a Catch block, a Finally block or both; the absent one is nil.
+type Try struct {
+	Body    Block
 	Name    lexer.Token
-	Methods []Function
+	Catch   Stmt
+	Finally Stmt
+}
+
+// Import binds a whole module to Alias, or only the listed Names of its
+// top-level definitions. Path is the string literal naming the file.
+type Import struct {
+	Keyword lexer.Token
+	Path    lexer.Token
+	Alias   *lexer.Token
+	Names   []lexer.Token
+}
+
+type Class struct {
+	Name       lexer.Token
+	Superclass Expr
+	Methods    []Function
 }
 
 func (e Expression) Printer() string {
@@ -63,6 +165,9 @@ func (p Print) Printer() string {
 }
 
 func (v Var) Printer() string {
+	if v.Const {
+		return fmt.Sprintf("const %v = %v;", v.Name, v.Initializer.Printer())
+	}
 	return fmt.Sprintf("var %v = %v;", v.Name, v.Initializer.Printer())
 }
 
@@ -86,7 +191,34 @@ func (w While) Printer() string {
 	return ""
 }
 
+func (m Match) Printer() string {
+	return fmt.Sprintf("match (%v)", m.Value.Printer())
+}
+
+func (s Spawn) Printer() string {
+	return fmt.Sprintf("spawn %v;", s.Call.Printer())
+}
+
+func (s Select) Printer() string {
+	return "select"
+}
+
+func (f ForIn) Printer() string {
+	return fmt.Sprintf("for (var %v in %v)", f.Name.Lexeme, f.Iterable.Printer())
+}
+
+func (b Break) Printer() string {
+	return "break;"
+}
+
+func (c Continue) Printer() string {
+	return "continue;"
+}
+
 func (f Function) Printer() string {
+	if f.Async {
+		return fmt.Sprintf("async fun %v", f.Name.Lexeme)
+	}
 	return fmt.Sprintf("fun %v", f.Name.Lexeme)
 }
 
@@ -95,5 +227,30 @@ func (r Return) Printer() string {
 }
 
 func (c Class) Printer() string {
+	if c.Superclass != nil {
+		return fmt.Sprintf("class %v < %v", c.Name.Lexeme, c.Superclass.Printer())
+	}
 	return fmt.Sprintf("class %v", c.Name.Lexeme)
 }
+
+func (y Yield) Printer() string {
+	if y.Value == nil {
+		return "yield;"
+	}
+	return fmt.Sprintf("yield %v;", y.Value.Printer())
+}
+
+func (t Throw) Printer() string {
+	return fmt.Sprintf("throw %v;", t.Value.Printer())
+}
+
+func (t Try) Printer() string {
+	return "try"
+}
+
+func (i Import) Printer() string {
+	if i.Alias != nil {
+		return fmt.Sprintf("import %v as %v;", i.Path.Lexeme, i.Alias.Lexeme)
+	}
+	return fmt.Sprintf("import %v;", i.Path.Lexeme)
+}
@@ -1,43 +1,155 @@
 package interpreter
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/umed-hotamov/golox/internal/ast"
+	"github.com/umed-hotamov/golox/internal/lexer"
 )
 
 type Callable interface {
-	arity() int
-	call(interpreter *Interpreter, arguments []any) any
+	arity() arityRange
+	call(interpreter *Interpreter, arguments []any) (any, error)
+}
+
+// arityRange is the number of arguments a callable accepts, from min to max.
+// max is -1 when any number of extra arguments is accepted.
+type arityRange struct {
+	min int
+	max int
+}
+
+func exactly(count int) arityRange {
+	return arityRange{min: count, max: count}
+}
+
+func (a arityRange) accepts(count int) bool {
+	return count >= a.min && (a.max < 0 || count <= a.max)
+}
+
+func (a arityRange) String() string {
+	switch {
+	case a.max < 0:
+		return fmt.Sprintf("at least %d", a.min)
+	case a.min == a.max:
+		return fmt.Sprint(a.min)
+	}
+
+	return fmt.Sprintf("%d to %d", a.min, a.max)
+}
+
+// argumentError is returned by native functions, which have no token to
+// report errors at, for arguments they can't take. The call reports it at
+// its parenthesis.
+type argumentError string
+
+func (e argumentError) Error() string {
+	return string(e)
+}
+
+// missingArgument fills the slots of parameters that named arguments skipped
+// over, so that they get their default value.
+type missingArgument struct{}
+
+var missing missingArgument
+
+// parameterized is implemented by callables whose parameters can be passed
+// by name.
+type parameterized interface {
+	parameters() []lexer.Token
 }
 
 type Function struct {
-	declaration ast.Function
-	closure     *Environment
+	declaration   ast.Function
+	closure       *Environment
+	isInitializer bool
 }
 
-func NewFunction(declaration ast.Function, closure *Environment) *Function {
+func NewFunction(declaration ast.Function, closure *Environment, isInitializer bool) *Function {
 	return &Function{
-		declaration: declaration,
-		closure:     closure,
+		declaration:   declaration,
+		closure:       closure,
+		isInitializer: isInitializer,
+	}
+}
+
+func (f *Function) arity() arityRange {
+	required := 0
+	for required < len(f.declaration.Params) && f.declaration.Defaults[required] == nil {
+		required++
+	}
+
+	if f.declaration.Rest != nil {
+		return arityRange{min: required, max: -1}
 	}
+	return arityRange{min: required, max: len(f.declaration.Params)}
+}
+
+func (f *Function) parameters() []lexer.Token {
+	return f.declaration.Params
 }
 
-func (f *Function) arity() int {
-	return len(f.declaration.Params)
+// bind returns a copy of the method whose closure has "this" set to instance.
+func (f *Function) bind(instance *LoxInstance) *Function {
+	env := NewEnclosingEnvironment(f.closure)
+	env.bind("this", instance)
+
+	return NewFunction(f.declaration, env, f.isInitializer)
 }
 
-func (f *Function) call(interpreter *Interpreter, arguments []any) (value any) {
+func (f *Function) call(interpreter *Interpreter, arguments []any) (any, error) {
 	env := NewEnclosingEnvironment(f.closure)
 
-	for i := 0; i < len(f.declaration.Params); i += 1 {
-		env.define(f.declaration.Params[i].Lexeme, arguments[i])
+	for i, param := range f.declaration.Params {
+		if i < len(arguments) && arguments[i] != missing {
+			env.bind(param.Lexeme, arguments[i])
+			continue
+		}
+
+		value, err := interpreter.evaluateIn(f.declaration.Defaults[i], env)
+		if err != nil {
+			return nil, err
+		}
+		env.bind(param.Lexeme, value)
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			value = r
+	if f.declaration.Rest != nil {
+		var rest []any
+		if len(arguments) > len(f.declaration.Params) {
+			rest = slices.Clone(arguments[len(f.declaration.Params):])
 		}
-	}()
+		env.bind(f.declaration.Rest.Lexeme, NewLoxList(rest))
+	}
+
+	if f.declaration.Generator {
+		return NewGenerator(f.declaration.Name, interpreter, f.declaration.Body, env), nil
+	}
+	if f.declaration.Async {
+		return startAsync(interpreter, f.declaration.Body, env), nil
+	}
+
+	err := interpreter.executeBlock(f.declaration.Body, env)
+
+	signal, returned := err.(*returnSignal)
+	if err != nil && !returned {
+		return nil, err
+	}
+
+	if f.isInitializer {
+		return f.closure.getAt(0, "this"), nil
+	}
+	if returned {
+		return signal.value, nil
+	}
+
+	return nil, nil
+}
+
+func (f *Function) String() string {
+	if f.declaration.Name.TokenType != lexer.IDENTIFIER {
+		return "<fn>"
+	}
 
-	interpreter.executeBlock(f.declaration.Body, env)
-	return
+	return fmt.Sprintf("<fn %s>", f.declaration.Name.Lexeme)
 }
@@ -0,0 +1,345 @@
+package interpreter
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// ChannelConstructor is the built-in 'Channel'. Channel() makes an
+// unbuffered channel and Channel(n) one that buffers up to n values.
+type ChannelConstructor struct {
+}
+
+func (c ChannelConstructor) arity() arityRange {
+	return arityRange{min: 0, max: 1}
+}
+
+func (c ChannelConstructor) call(interpreter *Interpreter, arguments []any) (any, error) {
+	if len(arguments) == 0 {
+		return NewChannel(&interpreter.goroutines, 0), nil
+	}
+
+	capacity, ok := toInteger(arguments[0])
+	if !ok || capacity < 0 {
+		return nil, argumentError("Channel capacity must be a non-negative integer")
+	}
+
+	return NewChannel(&interpreter.goroutines, int(capacity)), nil
+}
+
+func (c ChannelConstructor) String() string {
+	return "<native fn Channel>"
+}
+
+// goroutines counts the goroutines running Lox code, the main one and those
+// started with spawn, and guards the channels they share. Knowing which of
+// them wait on channels makes a deadlock an error: once all of them wait,
+// none of them can ever be woken.
+type goroutines struct {
+	mu      sync.Mutex
+	live    int
+	waiting []*waiter
+}
+
+// start counts a goroutine that starts running Lox code.
+func (g *goroutines) start() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.live++
+}
+
+// stop uncounts a goroutine that finished, or that waits for something other
+// than a channel, which the others can't wake it from.
+func (g *goroutines) stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.live--
+	g.detectDeadlock()
+}
+
+// detectDeadlock wakes the waiting goroutines with an error if no other is
+// left to wake them. g.mu must be held.
+func (g *goroutines) detectDeadlock() {
+	if len(g.waiting) == 0 || len(g.waiting) < g.live {
+		return
+	}
+
+	for _, w := range slices.Clone(g.waiting) {
+		g.wake(w, waiterResult{err: runtimeError(w.token, "Deadlock: every goroutine is blocked on a channel")})
+	}
+}
+
+// channelOp is a send or a receive a goroutine may wait on, on its own or
+// as one arm of a select.
+type channelOp struct {
+	channel *Channel
+	send    bool
+	value   any
+}
+
+func (op channelOp) ready() bool {
+	c := op.channel
+	if op.send {
+		return c.closed || len(c.receivers) > 0 || len(c.buffer) < c.capacity
+	}
+
+	return len(c.buffer) > 0 || len(c.senders) > 0 || c.closed
+}
+
+// waiter is a goroutine waiting until one of its operations completes.
+type waiter struct {
+	token   lexer.Token
+	pending []*pendingOp
+	woken   chan struct{}
+	result  waiterResult
+}
+
+type waiterResult struct {
+	chosen int
+	value  any
+	ok     bool
+	err    error
+}
+
+// pendingOp is an operation a waiter is queued with on its channel. index
+// is the operation's position among the waiter's operations.
+type pendingOp struct {
+	channelOp
+	waiter *waiter
+	index  int
+}
+
+// perform carries out one of ops, picked at random among those that can go
+// ahead right away like select does in Go. If none can and wait is set, it
+// waits until one can; otherwise chosen is -1. ok is false for a receive
+// from a closed channel, and errors are reported at token.
+func (g *goroutines) perform(token lexer.Token, ops []channelOp, wait bool) (chosen int, value any, ok bool, err error) {
+	g.mu.Lock()
+
+	var ready []int
+	for j, op := range ops {
+		if op.ready() {
+			ready = append(ready, j)
+		}
+	}
+	if len(ready) > 0 {
+		chosen = ready[rand.IntN(len(ready))]
+		op := ops[chosen]
+		if op.send {
+			err = g.send(token, op.channel, op.value)
+		} else {
+			value, ok = g.receive(op.channel)
+		}
+		g.mu.Unlock()
+		return chosen, value, ok, err
+	}
+	if !wait {
+		g.mu.Unlock()
+		return -1, nil, false, nil
+	}
+
+	w := &waiter{token: token, woken: make(chan struct{})}
+	for j, op := range ops {
+		pending := &pendingOp{channelOp: op, waiter: w, index: j}
+		w.pending = append(w.pending, pending)
+		if op.send {
+			op.channel.senders = append(op.channel.senders, pending)
+		} else {
+			op.channel.receivers = append(op.channel.receivers, pending)
+		}
+	}
+	g.waiting = append(g.waiting, w)
+	g.detectDeadlock()
+	g.mu.Unlock()
+
+	<-w.woken
+	return w.result.chosen, w.result.value, w.result.ok, w.result.err
+}
+
+// send hands value to the first waiting receiver, or buffers it. The channel
+// must be ready to send on. g.mu must be held.
+func (g *goroutines) send(token lexer.Token, c *Channel, value any) error {
+	if c.closed {
+		return runtimeError(token, "Send on closed channel")
+	}
+
+	if len(c.receivers) > 0 {
+		receiver := c.receivers[0]
+		g.wake(receiver.waiter, waiterResult{chosen: receiver.index, value: value, ok: true})
+		return nil
+	}
+
+	c.buffer = append(c.buffer, value)
+	return nil
+}
+
+// receive takes the oldest buffered value, letting the first waiting sender
+// refill the buffer, or takes the value of the first waiting sender on an
+// unbuffered channel. The channel must be ready to receive from. g.mu must
+// be held.
+func (g *goroutines) receive(c *Channel) (any, bool) {
+	if len(c.buffer) > 0 {
+		value := c.buffer[0]
+		c.buffer = c.buffer[1:]
+		if len(c.senders) > 0 {
+			sender := c.senders[0]
+			c.buffer = append(c.buffer, sender.value)
+			g.wake(sender.waiter, waiterResult{chosen: sender.index})
+		}
+		return value, true
+	}
+
+	if len(c.senders) > 0 {
+		sender := c.senders[0]
+		g.wake(sender.waiter, waiterResult{chosen: sender.index})
+		return sender.value, true
+	}
+
+	return nil, false
+}
+
+// wake ends the wait of w with result, taking its operations off the queues
+// of their channels. g.mu must be held.
+func (g *goroutines) wake(w *waiter, result waiterResult) {
+	for _, pending := range w.pending {
+		isPending := func(p *pendingOp) bool { return p == pending }
+		if c := pending.channel; pending.send {
+			c.senders = slices.DeleteFunc(c.senders, isPending)
+		} else {
+			c.receivers = slices.DeleteFunc(c.receivers, isPending)
+		}
+	}
+
+	w.result = result
+	g.waiting = slices.DeleteFunc(g.waiting, func(other *waiter) bool {
+		return other == w
+	})
+	close(w.woken)
+}
+
+// Channel passes values between goroutines started with spawn. Receiving
+// from a closed channel yields nil once the values sent before closing it
+// are drained; sending to or closing a closed channel is an error.
+type Channel struct {
+	goroutines *goroutines
+
+	// The fields below are guarded by goroutines.mu. Waiting senders are
+	// only queued while the buffer is full, and waiting receivers while it
+	// is empty.
+	capacity  int
+	buffer    []any
+	closed    bool
+	senders   []*pendingOp
+	receivers []*pendingOp
+}
+
+func NewChannel(goroutines *goroutines, capacity int) *Channel {
+	return &Channel{
+		goroutines: goroutines,
+		capacity:   capacity,
+	}
+}
+
+// send blocks until value is received or buffered.
+func (c *Channel) send(token lexer.Token, value any) error {
+	_, _, _, err := c.goroutines.perform(token, []channelOp{{channel: c, send: true, value: value}}, true)
+	return err
+}
+
+// receive blocks until a value is sent. ok is false when the channel is
+// closed and drained.
+func (c *Channel) receive(token lexer.Token) (value any, ok bool, err error) {
+	_, value, ok, err = c.goroutines.perform(token, []channelOp{{channel: c}}, true)
+	return value, ok, err
+}
+
+// close wakes the receivers waiting on the channel with nil, and fails the
+// senders.
+func (c *Channel) close(token lexer.Token) error {
+	g := c.goroutines
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c.closed {
+		return runtimeError(token, "Close of closed channel")
+	}
+	c.closed = true
+
+	for len(c.receivers) > 0 {
+		receiver := c.receivers[0]
+		g.wake(receiver.waiter, waiterResult{chosen: receiver.index})
+	}
+	for len(c.senders) > 0 {
+		sender := c.senders[0]
+		g.wake(sender.waiter, waiterResult{chosen: sender.index, err: runtimeError(sender.waiter.token, "Send on closed channel")})
+	}
+
+	return nil
+}
+
+func (c *Channel) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "send":
+		return NewNativeFunction("send", 1, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return nil, c.send(name, arguments[0])
+		}), nil
+	case "receive":
+		return NewNativeFunction("receive", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			value, _, err := c.receive(name)
+			return value, err
+		}), nil
+	case "close":
+		return NewNativeFunction("close", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return nil, c.close(name)
+		}), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+// Looping over a channel receives values until it is closed.
+func (c *Channel) iterator(interpreter *Interpreter, token lexer.Token) Iterator {
+	return &channelIterator{channel: c, token: token}
+}
+
+func (c *Channel) String() string {
+	return "<channel>"
+}
+
+// channelIterator receives ahead in hasNext, since only a receive tells
+// whether the channel is closed, and keeps the value until next asks for it.
+type channelIterator struct {
+	channel  *Channel
+	token    lexer.Token
+	buffered bool
+	value    any
+	closed   bool
+}
+
+func (c *channelIterator) hasNext() (bool, error) {
+	if !c.buffered && !c.closed {
+		var err error
+		if c.value, c.buffered, err = c.channel.receive(c.token); err != nil {
+			return false, err
+		}
+		c.closed = !c.buffered
+	}
+
+	return c.buffered, nil
+}
+
+func (c *channelIterator) next() (any, error) {
+	if more, err := c.hasNext(); !more {
+		return nil, err
+	}
+
+	value := c.value
+	c.buffered, c.value = false, nil
+	return value, nil
+}
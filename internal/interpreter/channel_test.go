@@ -0,0 +1,141 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/umed-hotamov/golox/internal/interpreter"
+)
+
+func TestChannels(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name: "unbuffered",
+			source: `
+				var c = Channel();
+				fun send() { c.send(1); c.send(2); }
+				spawn send();
+				print c.receive();
+				print c.receive();
+			`,
+			want: "1\n2\n",
+		},
+		{
+			name: "buffered",
+			source: `
+				var c = Channel(2);
+				c.send("a");
+				c.send("b");
+				print c.receive();
+				print c.receive();
+			`,
+			want: "a\nb\n",
+		},
+		{
+			name: "full buffer blocks the sender",
+			source: `
+				var c = Channel(1);
+				fun fill() { c.send(1); c.send(2); c.send(3); c.close(); }
+				spawn fill();
+				for (var x in c) print x;
+			`,
+			want: "1\n2\n3\n",
+		},
+		{
+			name: "select with default",
+			source: `
+				var c = Channel(1);
+				select {
+					case var v = c.receive() => print v;
+					default => print "default";
+				}
+				c.send(1);
+				select {
+					case var v = c.receive() => print v;
+					default => print "default";
+				}
+			`,
+			want: "default\n1\n",
+		},
+		{
+			name: "select without default",
+			source: `
+				var a = Channel();
+				var b = Channel();
+				fun send() { b.send("b"); }
+				spawn send();
+				select {
+					case var v = a.receive() => print "a";
+					case var v = b.receive() => print v;
+				}
+				var c = Channel(1);
+				select {
+					case c.send(1) => print "sent";
+				}
+				print c.receive();
+			`,
+			want: "b\nsent\n1\n",
+		},
+		{
+			name: "close wakes blocked receivers",
+			source: `
+				var c = Channel();
+				fun receive() { print c.receive(); }
+				spawn receive();
+				spawn receive();
+				setTimeout(fun () { c.close(); }, 10);
+			`,
+			want: "nil\nnil\n",
+		},
+		{
+			name: "close fails blocked senders",
+			source: `
+				var c = Channel();
+				fun send() {
+					try { c.send(1); } catch (e) { print e.message; }
+				}
+				spawn send();
+				setTimeout(fun () { c.close(); }, 10);
+			`,
+			want: "Send on closed channel\n",
+		},
+		{
+			name: "deadlock",
+			source: `
+				var c = Channel();
+				try { c.receive(); } catch (e) { print e.message; }
+				fun receive() {
+					try { c.receive(); } catch (e) { print e.message; }
+				}
+				spawn receive();
+			`,
+			want: "Deadlock: every goroutine is blocked on a channel\n" +
+				"Deadlock: every goroutine is blocked on a channel\n",
+		},
+		{
+			name: "pending timer feeds a blocked goroutine",
+			source: `
+				var c = Channel();
+				setTimeout(fun () { c.send("fed"); }, 10);
+				fun receive() { print c.receive(); }
+				spawn receive();
+			`,
+			want: "fed\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Goroutines interleave differently from run to run.
+			for range 5 {
+				i := interpreter.NewInterpreter()
+				if output := run(t, i, test.source); output != test.want {
+					t.Fatalf("got output %q, want %q", output, test.want)
+				}
+			}
+		})
+	}
+}
@@ -1,15 +1,62 @@
 package interpreter
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
 
 type LoxClass struct {
-	name string
+	name       string
+	superclass *LoxClass
+	methods    map[string]*Function
 }
 
-func NewLoxClass(name string) *LoxClass {
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*Function) *LoxClass {
 	return &LoxClass{
-		name: name,
+		name:       name,
+		superclass: superclass,
+		methods:    methods,
+	}
+}
+
+func (l *LoxClass) findMethod(name string) *Function {
+	if method, ok := l.methods[name]; ok {
+		return method
+	}
+
+	if l.superclass != nil {
+		return l.superclass.findMethod(name)
+	}
+
+	return nil
+}
+
+func (l *LoxClass) arity() arityRange {
+	if initializer := l.findMethod("init"); initializer != nil {
+		return initializer.arity()
 	}
+
+	return exactly(0)
+}
+
+func (l *LoxClass) parameters() []lexer.Token {
+	if initializer := l.findMethod("init"); initializer != nil {
+		return initializer.parameters()
+	}
+
+	return nil
+}
+
+func (l *LoxClass) call(interpreter *Interpreter, arguments []any) (any, error) {
+	instance := NewLoxInstance(l)
+	if initializer := l.findMethod("init"); initializer != nil {
+		if _, err := initializer.bind(instance).call(interpreter, arguments); err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
 }
 
 func (l *LoxClass) String() string {
@@ -2,13 +2,19 @@ package interpreter
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/umed-hotamov/golox/internal/lexer"
 )
 
+// Environment is safe for concurrent use: closures running on spawned
+// goroutines share the environments they captured.
 type Environment struct {
+	mu        sync.RWMutex
 	objects   map[string]any
+	constants map[string]bool
 	enclosing *Environment
+	module    *Module
 }
 
 func NewEnvironment() *Environment {
@@ -20,32 +26,86 @@ func NewEnvironment() *Environment {
 func NewEnclosingEnvironment(env *Environment) *Environment {
 	enclosingEnv := NewEnvironment()
 	enclosingEnv.enclosing = env
+	enclosingEnv.module = env.module
 	return enclosingEnv
 }
 
-func (e *Environment) define(name string, value any) {
+// define binds name to value, replacing any earlier binding unless that is
+// a constant.
+func (e *Environment) define(name lexer.Token, value any) error {
+	return e.declare(name, value, false)
+}
+
+func (e *Environment) defineConstant(name lexer.Token, value any) error {
+	return e.declare(name, value, true)
+}
+
+func (e *Environment) declare(name lexer.Token, value any, constant bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.constants[name.Lexeme] {
+		return runtimeError(name, fmt.Sprintf("Can't redeclare constant '%s'", name.Lexeme))
+	}
+
+	e.objects[name.Lexeme] = value
+	if constant {
+		if e.constants == nil {
+			e.constants = make(map[string]bool)
+		}
+		e.constants[name.Lexeme] = true
+	}
+	return nil
+}
+
+// bind binds a name in an environment the interpreter made for it, such as
+// a parameter or 'this', where no constant can be in the way.
+func (e *Environment) bind(name string, value any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	e.objects[name] = value
 }
 
-func (e *Environment) get(token lexer.Token) any {
-	if value, ok := e.objects[token.Lexeme]; ok {
-		return value
+// lookup returns the value bound to name in this environment only.
+func (e *Environment) lookup(name string) (any, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	value, ok := e.objects[name]
+	return value, ok
+}
+
+func (e *Environment) isConstant(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.constants[name]
+}
+
+func (e *Environment) get(token lexer.Token) (any, error) {
+	if value, ok := e.lookup(token.Lexeme); ok {
+		return value, nil
 	}
 
 	if e.enclosing != nil {
 		return e.enclosing.get(token)
 	}
 
-	panic(fmt.Sprintf("Undefined variable %s\n", token.Lexeme))
+	return nil, runtimeError(token, fmt.Sprintf("Undefined variable '%s'", token.Lexeme))
 }
 
 func (e *Environment) getAt(distance int, value string) any {
-	ancestor := e.ancestor(distance)
-	return ancestor.objects[value]
+	object, _ := e.ancestor(distance).lookup(value)
+	return object
 }
 
 func (e *Environment) assignAt(distance int, name lexer.Token, value any) {
 	ancestor := e.ancestor(distance)
+
+	ancestor.mu.Lock()
+	defer ancestor.mu.Unlock()
+
 	ancestor.objects[name.Lexeme] = value
 }
 
@@ -58,16 +118,31 @@ func (e *Environment) ancestor(distance int) *Environment {
 	return a
 }
 
-func (e *Environment) assign(name lexer.Token, value any) {
-	if _, ok := e.objects[name.Lexeme]; ok {
-		e.objects[name.Lexeme] = value
-		return
+func (e *Environment) assign(name lexer.Token, value any) error {
+	if bound, err := e.assignHere(name, value); bound {
+		return err
 	}
 
 	if e.enclosing != nil {
-		e.enclosing.assign(name, value)
-		return
+		return e.enclosing.assign(name, value)
+	}
+
+	return runtimeError(name, fmt.Sprintf("Undefined variable '%s'", name.Lexeme))
+}
+
+// assignHere assigns to name if it is bound in this environment, reporting
+// whether it is.
+func (e *Environment) assignHere(name lexer.Token, value any) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.objects[name.Lexeme]; !ok {
+		return false, nil
+	}
+	if e.constants[name.Lexeme] {
+		return true, runtimeError(name, fmt.Sprintf("Can't assign to constant '%s'", name.Lexeme))
 	}
 
-	panic(fmt.Sprintf("Undefined variable %s\n", name.Lexeme))
+	e.objects[name.Lexeme] = value
+	return true, nil
 }
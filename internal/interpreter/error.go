@@ -0,0 +1,51 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// RuntimeError is raised when an operation fails at runtime. Scripts can
+// catch it like any thrown value; it exposes message and line properties.
+type RuntimeError struct {
+	token   lexer.Token
+	message string
+}
+
+func NewRuntimeError(token lexer.Token, message string) *RuntimeError {
+	return &RuntimeError{
+		token:   token,
+		message: message,
+	}
+}
+
+func (e *RuntimeError) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "message":
+		return e.message, nil
+	case "line":
+		return int64(e.token.Line), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("[line: %d , at %s] Error: %s", e.token.Line, e.token.Lexeme, e.message)
+}
+
+// Throw carries a value raised by a throw statement up to the nearest
+// enclosing catch clause.
+type Throw struct {
+	keyword lexer.Token
+	value   any
+}
+
+func (t *Throw) Error() string {
+	return fmt.Sprintf("[line: %d , at %s] Error: Uncaught %s", t.keyword.Line, t.keyword.Lexeme, stringify(t.value))
+}
+
+func runtimeError(token lexer.Token, message string) error {
+	return NewRuntimeError(token, message)
+}
@@ -0,0 +1,331 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// TimeSource is the clock the event loop schedules timers by.
+type TimeSource interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realTime struct{}
+
+func (realTime) Now() time.Time {
+	return time.Now()
+}
+
+func (realTime) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// VirtualTime is a TimeSource for tests: its time only moves when the event
+// loop sleeps, and then instantly, so timers fire in order without any real
+// waiting.
+type VirtualTime struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewVirtualTime() *VirtualTime {
+	return &VirtualTime{}
+}
+
+func (v *VirtualTime) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.now
+}
+
+func (v *VirtualTime) Sleep(d time.Duration) {
+	v.Advance(d)
+}
+
+// Advance moves the time forward by d.
+func (v *VirtualTime) Advance(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.now = v.now.Add(d)
+}
+
+// job is a piece of work the event loop runs, such as a promise callback.
+type job func(interpreter *Interpreter) error
+
+type timer struct {
+	id       int64
+	due      time.Time
+	interval time.Duration
+	callback Callable
+}
+
+// EventLoop runs timer callbacks and promise reactions one at a time, on the
+// goroutine that calls Interpret, once the script's statements have run.
+// Jobs queued by settled promises run before the next timer fires. Spawned
+// goroutines may schedule work too, so the loop's state is locked.
+type EventLoop struct {
+	mu   sync.Mutex
+	time TimeSource
+
+	// goroutines counts the goroutine running the loop as live, except while
+	// it waits in wait for spawned goroutines to give it work. work is
+	// signalled when they do, or when one of them finishes.
+	goroutines *goroutines
+	work       *sync.Cond
+	waiting    bool
+	// tasks is the number of spawned goroutines still running.
+	tasks int
+
+	jobs []job
+	// timers is ordered by due time, then by id, so timers due at the same
+	// time fire in the order they were set.
+	timers []*timer
+	nextID int64
+
+	// rejected holds promises rejected while nothing handled them. The ones
+	// still unhandled when the jobs run out are reported.
+	rejected []*Promise
+}
+
+func NewEventLoop(goroutines *goroutines) *EventLoop {
+	loop := &EventLoop{
+		time:       realTime{},
+		goroutines: goroutines,
+	}
+	loop.work = sync.NewCond(&loop.mu)
+
+	return loop
+}
+
+func (l *EventLoop) enqueue(j job) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.jobs = append(l.jobs, j)
+	l.wake()
+}
+
+// wake wakes the goroutine waiting for work, counting it as live again right
+// away: the goroutine giving it work may block on a channel before the
+// waiting one runs. l.mu must be held.
+func (l *EventLoop) wake() {
+	if l.waiting {
+		l.waiting = false
+		l.goroutines.start()
+	}
+	l.work.Broadcast()
+}
+
+func (l *EventLoop) taskStarted() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tasks++
+}
+
+func (l *EventLoop) taskDone() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tasks--
+	l.wake()
+}
+
+// wait waits while spawned goroutines are running and the loop has nothing
+// to run, and reports whether it has something now. Only the spawned
+// goroutines can wake each other meanwhile, so the waiting goroutine isn't
+// counted as live.
+func (l *EventLoop) wait() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.tasks > 0 && l.empty() {
+		if !l.waiting {
+			l.waiting = true
+			l.goroutines.stop()
+		}
+		l.work.Wait()
+	}
+	if l.waiting {
+		l.waiting = false
+		l.goroutines.start()
+	}
+
+	return !l.empty()
+}
+
+// schedule sets a timer calling callback after delay, and then every
+// interval if interval isn't zero. It returns the timer's id.
+func (l *EventLoop) schedule(callback Callable, delay time.Duration, interval time.Duration) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	l.insert(&timer{
+		id:       l.nextID,
+		due:      l.time.Now().Add(max(delay, 0)),
+		interval: interval,
+		callback: callback,
+	})
+	l.wake()
+
+	return l.nextID
+}
+
+func (l *EventLoop) insert(t *timer) {
+	position, _ := slices.BinarySearchFunc(l.timers, t, func(a, b *timer) int {
+		if c := a.due.Compare(b.due); c != 0 {
+			return c
+		}
+		return int(a.id - b.id)
+	})
+
+	l.timers = slices.Insert(l.timers, position, t)
+}
+
+// cancel stops the timer with the given id. Unknown ids are ignored.
+func (l *EventLoop) cancel(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timers = slices.DeleteFunc(l.timers, func(t *timer) bool {
+		return t.id == id
+	})
+}
+
+func (l *EventLoop) rejectedUnhandled(p *Promise) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rejected = append(l.rejected, p)
+}
+
+// run runs jobs and timers until there are none left, waiting for timers
+// that aren't due yet. A timer callback failing stops the loop.
+func (l *EventLoop) run(interpreter *Interpreter) error {
+	for {
+		if j := l.nextJob(); j != nil {
+			if err := j(interpreter); err != nil {
+				return err
+			}
+			continue
+		}
+
+		l.reportRejections()
+
+		t, ok := l.nextTimer()
+		if !ok {
+			return nil
+		}
+		if t == nil {
+			continue
+		}
+		if _, err := t.callback.call(interpreter, nil); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *EventLoop) nextJob() job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.jobs) == 0 {
+		return nil
+	}
+
+	j := l.jobs[0]
+	l.jobs = l.jobs[1:]
+	return j
+}
+
+// nextTimer returns the earliest timer if it is due, scheduling it again
+// if it repeats. If it isn't due yet, nextTimer waits for it and returns
+// nil, since spawned goroutines may have queued jobs or changed the timers
+// in the meantime. ok is false when there are no timers.
+func (l *EventLoop) nextTimer() (t *timer, ok bool) {
+	l.mu.Lock()
+	if len(l.timers) == 0 {
+		l.mu.Unlock()
+		return nil, false
+	}
+
+	t = l.timers[0]
+	if wait := t.due.Sub(l.time.Now()); wait > 0 {
+		l.mu.Unlock()
+		l.time.Sleep(wait)
+		return nil, true
+	}
+
+	l.timers = l.timers[1:]
+	if t.interval > 0 {
+		l.insert(&timer{id: t.id, due: t.due.Add(t.interval), interval: t.interval, callback: t.callback})
+	}
+	l.mu.Unlock()
+
+	return t, true
+}
+
+func (l *EventLoop) reportRejections() {
+	l.mu.Lock()
+	rejected := l.rejected
+	l.rejected = nil
+	l.mu.Unlock()
+
+	for _, p := range rejected {
+		if p.isHandled() {
+			continue
+		}
+
+		_, value, err := p.outcome()
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			fmt.Printf("Error: Uncaught (in promise) %s\n", stringify(value))
+		}
+	}
+}
+
+// empty reports whether the loop has nothing left to run. l.mu must be held.
+func (l *EventLoop) empty() bool {
+	return len(l.jobs) == 0 && len(l.timers) == 0
+}
+
+// setTimer returns the built-in that calls a callback after a delay given in
+// milliseconds, once or, for repeat, every time the delay passes again. The
+// built-in returns an id to cancel the timer with.
+func setTimer(repeat bool) func(interpreter *Interpreter, arguments []any) (any, error) {
+	return func(interpreter *Interpreter, arguments []any) (any, error) {
+		callback, ok := arguments[0].(Callable)
+		if !ok || !callback.arity().accepts(0) {
+			return nil, argumentError("Timer callback must be a function taking no arguments")
+		}
+		if !isNumber(arguments[1]) {
+			return nil, argumentError("Timer delay must be a number of milliseconds")
+		}
+
+		delay := time.Duration(toFloat(arguments[1]) * float64(time.Millisecond))
+		if !repeat {
+			return interpreter.loop.schedule(callback, delay, 0), nil
+		}
+		if delay <= 0 {
+			return nil, argumentError("Interval must be positive")
+		}
+		return interpreter.loop.schedule(callback, delay, delay), nil
+	}
+}
+
+func clearTimer(interpreter *Interpreter, arguments []any) (any, error) {
+	id, ok := arguments[0].(int64)
+	if !ok {
+		return nil, argumentError("Timer id must be an integer")
+	}
+
+	interpreter.loop.cancel(id)
+	return nil, nil
+}
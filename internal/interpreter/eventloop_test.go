@@ -0,0 +1,117 @@
+package interpreter_test
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umed-hotamov/golox/internal/interpreter"
+	"github.com/umed-hotamov/golox/internal/lexer"
+	"github.com/umed-hotamov/golox/internal/parser"
+	"github.com/umed-hotamov/golox/internal/resolver"
+)
+
+// run interprets source and returns what it prints.
+func run(t *testing.T, i *interpreter.Interpreter, source string) string {
+	t.Helper()
+
+	l := lexer.NewLexer(source)
+	p := parser.NewParser(l.Lex())
+	statements := p.Parse()
+	if l.HasError || p.HasError {
+		t.Fatalf("failed to parse %q", source)
+	}
+	r := resolver.NewResolver(i)
+	r.Resolve(statements)
+	if r.HasError {
+		t.Fatalf("failed to resolve %q", source)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	i.Interpret(statements)
+	writer.Close()
+
+	return <-output
+}
+
+func TestEventLoopVirtualTime(t *testing.T) {
+	clock := interpreter.NewVirtualTime()
+	i := interpreter.NewInterpreter()
+	i.SetTimeSource(clock)
+
+	source := `
+		setTimeout(fun () { print "timeout"; }, 3000);
+
+		var ticks = 0;
+		var id = setInterval(fun () {
+			ticks++;
+			print "tick ${ticks}";
+			if (ticks == 3) clearInterval(id);
+		}, 1000);
+
+		async fun wait() {
+			print "waiting";
+			var value = await Promise(fun (resolve, reject) {
+				setTimeout(fun () { resolve("resolved"); }, 2500);
+			});
+			print value;
+		}
+		wait();
+		print "scheduled";
+	`
+
+	start := time.Now()
+	output := run(t, i, source)
+	elapsed := time.Since(start)
+
+	// The timeout and the interval's third tick are both due at 3000ms, and
+	// fire in the order they were set.
+	want := strings.Join([]string{"waiting", "scheduled", "tick 1", "tick 2", "resolved", "timeout", "tick 3"}, "\n") + "\n"
+	if output != want {
+		t.Errorf("got output %q, want %q", output, want)
+	}
+
+	if now := clock.Now().Sub(time.Time{}); now != 3*time.Second {
+		t.Errorf("got virtual time %v after the last timer, want 3s", now)
+	}
+	if elapsed > time.Second {
+		t.Errorf("running the timers took %v of real time", elapsed)
+	}
+}
+
+func TestEventLoopRunsTimersOfSpawnedGoroutines(t *testing.T) {
+	i := interpreter.NewInterpreter()
+	i.SetTimeSource(interpreter.NewVirtualTime())
+
+	// The timer is set after the main goroutine ran out of statements, while
+	// it waits for the spawned one.
+	source := `
+		var ch = Channel();
+		fun worker() {
+			setTimeout(fun () { ch.send("from timer"); }, 10);
+			print ch.receive();
+		}
+		spawn worker();
+	`
+
+	for range 20 {
+		if output, want := run(t, i, source), "from timer\n"; output != want {
+			t.Fatalf("got output %q, want %q", output, want)
+		}
+	}
+}
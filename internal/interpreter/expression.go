@@ -1,13 +1,16 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/umed-hotamov/golox/internal/ast"
 	"github.com/umed-hotamov/golox/internal/lexer"
 )
 
-func (i *Interpreter) evaluate(expression ast.Expr) any {
+func (i *Interpreter) evaluate(expression ast.Expr) (any, error) {
 	switch expression.(type) {
 	case ast.Literal:
 		return i.evaluateLiteral(expression.(ast.Literal))
@@ -21,129 +24,601 @@ func (i *Interpreter) evaluate(expression ast.Expr) any {
 		return i.evaluateVariable(expression.(ast.Variable))
 	case ast.Assign:
 		return i.evaluateAssign(expression.(ast.Assign))
+	case ast.Update:
+		return i.evaluateUpdate(expression.(ast.Update))
 	case ast.Logical:
 		return i.evaluateLogical(expression.(ast.Logical))
+	case ast.Conditional:
+		return i.evaluateConditional(expression.(ast.Conditional))
+	case ast.Range:
+		return i.evaluateRange(expression.(ast.Range))
 	case ast.Call:
 		return i.evaluateCall(expression.(ast.Call))
+	case ast.Get:
+		return i.evaluateGet(expression.(ast.Get))
+	case ast.Set:
+		return i.evaluateSet(expression.(ast.Set))
+	case ast.This:
+		return i.evaluateThis(expression.(ast.This))
+	case ast.Super:
+		return i.evaluateSuper(expression.(ast.Super))
+	case ast.List:
+		return i.evaluateList(expression.(ast.List))
+	case ast.Map:
+		return i.evaluateMap(expression.(ast.Map))
+	case ast.Lambda:
+		return i.evaluateLambda(expression.(ast.Lambda))
+	case ast.Await:
+		return i.evaluateAwait(expression.(ast.Await))
+	case ast.Interpolation:
+		return i.evaluateInterpolation(expression.(ast.Interpolation))
+	case ast.OptionalChain:
+		return i.evaluateOptionalChain(expression.(ast.OptionalChain))
+	case ast.Index:
+		return i.evaluateIndex(expression.(ast.Index))
+	case ast.SetIndex:
+		return i.evaluateSetIndex(expression.(ast.SetIndex))
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (i *Interpreter) evaluateLiteral(expression ast.Literal) any {
-	return expression.Value
+// evaluateIn evaluates expression with env as the current environment.
+func (i *Interpreter) evaluateIn(expression ast.Expr, env *Environment) (any, error) {
+	previous := i.env
+	i.env = env
+	defer func() { i.env = previous }()
+
+	return i.evaluate(expression)
+}
+
+func (i *Interpreter) evaluateLiteral(expression ast.Literal) (any, error) {
+	return expression.Value, nil
 }
 
-func (i *Interpreter) evaluateGrouping(expression ast.Grouping) any {
+func (i *Interpreter) evaluateGrouping(expression ast.Grouping) (any, error) {
 	return i.evaluate(expression.Expr)
 }
 
-func (i *Interpreter) evaluateUnary(expression ast.Unary) any {
-	right := i.evaluate(expression.Right)
+func (i *Interpreter) evaluateUnary(expression ast.Unary) (any, error) {
+	right, err := i.evaluate(expression.Right)
+	if err != nil {
+		return nil, err
+	}
 
 	switch expression.Operator.TokenType {
 	case lexer.BANG:
-		return !isTruthy(right)
+		return !isTruthy(right), nil
 	case lexer.MINUS:
-		return -right.(float64)
+		if instance := withMethod(right, "__neg__"); instance != nil {
+			return i.callSpecial(expression.Operator, instance, "__neg__")
+		}
+		if err := checkNumberOperand(expression.Operator, right); err != nil {
+			return nil, err
+		}
+		return negate(expression.Operator, right)
+	case lexer.TILDE:
+		if err := checkIntegerOperands(expression.Operator, right); err != nil {
+			return nil, err
+		}
+		return ^right.(int64), nil
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (i *Interpreter) evaluateBinary(expression ast.Binary) any {
-	left := i.evaluate(expression.Left)
-	right := i.evaluate(expression.Right)
+func (i *Interpreter) evaluateBinary(expression ast.Binary) (any, error) {
+	left, err := i.evaluate(expression.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := i.evaluate(expression.Right)
+	if err != nil {
+		return nil, err
+	}
 
-	switch expression.Operator.TokenType {
+	return i.binary(expression.Operator, left, right)
+}
+
+// binary applies a binary operator to already evaluated operands.
+func (i *Interpreter) binary(operator lexer.Token, left any, right any) (any, error) {
+	if result, ok, err := i.overload(operator, left, right); ok {
+		return result, err
+	}
+
+	switch operator.TokenType {
 	case lexer.EQUAL_EQUAL:
-		return isEqual(left, right)
+		return i.equal(operator, left, right)
 	case lexer.BANG_EQUAL:
-		return !isEqual(left, right)
-	case lexer.GREATER:
-		return left.(float64) > right.(float64)
-	case lexer.LESS:
-		return left.(float64) < right.(float64)
-	case lexer.GREATER_EQUAL:
-		return left.(float64) >= right.(float64)
-	case lexer.LESS_EQUAL:
-		return left.(float64) <= right.(float64)
-	case lexer.STAR:
-		return left.(float64) * right.(float64)
-	case lexer.SLASH:
-		return left.(float64) / right.(float64)
-	case lexer.MINUS:
-		return left.(float64) - right.(float64)
+		equal, err := i.equal(operator, left, right)
+		return !equal, err
+	case lexer.IN:
+		return contains(i, operator, left, right)
 	case lexer.PLUS:
 		if isNumber(left) && isNumber(right) {
-			return left.(float64) + right.(float64)
+			return arithmetic(operator, left, right)
 		}
 		if isString(left) && isString(right) {
-			return left.(string) + right.(string)
+			return left.(string) + right.(string), nil
 		}
 
-		runtimeError(expression.Operator, "Operands must be either numbers or strings")
+		return nil, runtimeError(operator, "Operands must be either numbers or strings")
+	}
+
+	if err := checkNumberOperands(operator, left, right); err != nil {
+		return nil, err
 	}
 
-	return nil
+	switch operator.TokenType {
+	case lexer.GREATER, lexer.GREATER_EQUAL, lexer.LESS, lexer.LESS_EQUAL:
+		return compareNumbers(operator, left, right), nil
+	case lexer.AMPERSAND, lexer.PIPE, lexer.CARET, lexer.LESS_LESS, lexer.GREATER_GREATER:
+		if err := checkIntegerOperands(operator, left, right); err != nil {
+			return nil, err
+		}
+		return bitwise(operator, left.(int64), right.(int64))
+	}
+
+	return arithmetic(operator, left, right)
 }
 
-func (i *Interpreter) evaluateVariable(expression ast.Variable) any {
-	return i.lookUpVariable(expression.Name, expression)
+func (i *Interpreter) evaluateVariable(expression ast.Variable) (any, error) {
+	return i.lookUpVariable(expression.Name)
 }
 
-func (i *Interpreter) lookUpVariable(name lexer.Token, expression ast.Expr) any {
-	distance, ok := i.locals[expression]
+func (i *Interpreter) lookUpVariable(name lexer.Token) (any, error) {
+	distance, ok := i.env.module.depth(name)
 	if ok {
-		return i.env.getAt(distance, name.Lexeme)
+		return i.env.getAt(distance, name.Lexeme), nil
 	}
 
-	return i.globals.get(name)
+	return i.env.module.globals.get(name)
 }
 
-func (i *Interpreter) evaluateAssign(expression ast.Assign) any {
-	value := i.evaluate(expression.Value)
+func (i *Interpreter) evaluateAssign(expression ast.Assign) (any, error) {
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.assignVariable(expression.Name, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
 
-	distance, ok := i.locals[expression]
+func (i *Interpreter) assignVariable(name lexer.Token, value any) error {
+	distance, ok := i.env.module.depth(name)
 	if ok {
-		i.env.assignAt(distance, expression.Name, value)
-	} else {
-		i.globals.assign(expression.Name, value)
+		i.env.assignAt(distance, name, value)
+		return nil
+	}
+
+	return i.env.module.globals.assign(name, value)
+}
+
+// evaluateUpdate evaluates the target's object and index only once, so
+// updates like xs[next()] += 1 run their side effects a single time.
+func (i *Interpreter) evaluateUpdate(expression ast.Update) (any, error) {
+	var (
+		get func() (any, error)
+		set func(value any) error
+	)
+
+	switch target := expression.Target.(type) {
+	case ast.Variable:
+		get = func() (any, error) { return i.lookUpVariable(target.Name) }
+		set = func(value any) error { return i.assignVariable(target.Name, value) }
+	case ast.Get:
+		object, err := i.evaluate(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		instance, ok := object.(*LoxInstance)
+		if !ok {
+			return nil, runtimeError(target.Name, "Only instances have fields")
+		}
+
+		get = func() (any, error) { return instance.get(target.Name) }
+		set = func(value any) error {
+			instance.set(target.Name, value)
+			return nil
+		}
+	case ast.Index:
+		object, err := i.evaluate(target.Object)
+		if err != nil {
+			return nil, err
+		}
+		index, err := i.evaluate(target.Index)
+		if err != nil {
+			return nil, err
+		}
+		indexable, err := i.indexable(target.Bracket, object)
+		if err != nil {
+			return nil, err
+		}
+
+		get = func() (any, error) { return indexable.getIndex(target.Bracket, index) }
+		set = func(value any) error { return indexable.setIndex(target.Bracket, index, value) }
+	}
+
+	old, err := get()
+	if err != nil {
+		return nil, err
+	}
+	operand, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, err
+	}
+	value, err := i.binary(expression.Operator, old, operand)
+	if err != nil {
+		return nil, err
+	}
+	if err := set(value); err != nil {
+		return nil, err
 	}
 
-	return value
+	if expression.Postfix {
+		return old, nil
+	}
+
+	return value, nil
 }
 
-func (i *Interpreter) evaluateLogical(expression ast.Logical) any {
-	left := i.evaluate(expression.Left)
-	if expression.Operator.TokenType == lexer.OR {
+func (i *Interpreter) evaluateLogical(expression ast.Logical) (any, error) {
+	left, err := i.evaluate(expression.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expression.Operator.TokenType {
+	case lexer.OR:
 		if isTruthy(left) {
-			return left
+			return left, nil
+		}
+	case lexer.QUESTION_QUESTION:
+		if left != nil {
+			return left, nil
 		}
-	} else {
+	default:
 		if !isTruthy(left) {
-			return left
+			return left, nil
 		}
 	}
 
 	return i.evaluate(expression.Right)
 }
 
-func (i *Interpreter) evaluateCall(expression ast.Call) any {
-	callee := i.evaluate(expression.Callee)
+func (i *Interpreter) evaluateConditional(expression ast.Conditional) (any, error) {
+	condition, err := i.evaluate(expression.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(condition) {
+		return i.evaluate(expression.Then)
+	}
+
+	return i.evaluate(expression.Else)
+}
+
+func (i *Interpreter) evaluateRange(expression ast.Range) (any, error) {
+	bounds := []ast.Expr{expression.Start, expression.End}
+	if expression.Step != nil {
+		bounds = append(bounds, expression.Step)
+	}
+
+	values := []int64{0, 0, 1}
+	for j, bound := range bounds {
+		value, err := i.evaluate(bound)
+		if err != nil {
+			return nil, err
+		}
+
+		integer, ok := toInteger(value)
+		if !ok {
+			return nil, runtimeError(expression.Operator, "Range bounds and step must be integers")
+		}
+		values[j] = integer
+	}
+
+	if values[2] == 0 {
+		return nil, runtimeError(expression.Operator, "Range step can't be zero")
+	}
+
+	return NewLoxRange(values[0], values[1], values[2], expression.Operator.TokenType == lexer.DOT_DOT), nil
+}
+
+func (i *Interpreter) evaluateCall(expression ast.Call) (any, error) {
+	function, arguments, err := i.prepareCall(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.call(expression.Paren, function, arguments)
+}
 
-	var arguments []any
+// prepareCall evaluates the callee and arguments of a call and checks them
+// against each other.
+func (i *Interpreter) prepareCall(expression ast.Call) (Callable, []any, error) {
+	callee, err := i.evaluate(expression.Callee)
+	if err != nil {
+		return nil, nil, err
+	}
+	if callee == nil && expression.Optional {
+		return nil, nil, errSkipChain
+	}
+
+	arguments := make([]any, 0, len(expression.Arguments))
 	for _, arg := range expression.Arguments {
-		arguments = append(arguments, i.evaluate(arg))
+		argument, err := i.evaluate(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		arguments = append(arguments, argument)
 	}
-	_, ok := callee.(Callable)
+	namedArguments := make([]any, 0, len(expression.NamedArguments))
+	for _, arg := range expression.NamedArguments {
+		argument, err := i.evaluate(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		namedArguments = append(namedArguments, argument)
+	}
+
+	function, ok := callee.(Callable)
 	if !ok {
-		runtimeError(expression.Paren, "Call only call functions and classes")
+		return nil, nil, runtimeError(expression.Paren, "Call only call functions and classes")
+	}
+
+	if len(expression.Names) > 0 {
+		arguments, err = placeNamedArguments(function, arguments, expression.Names, namedArguments)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if arity := function.arity(); !arity.accepts(len(arguments)) {
+		noun := "arguments"
+		if arity.min == 1 && (arity.max == 1 || arity.max < 0) {
+			noun = "argument"
+		}
+		return nil, nil, runtimeError(expression.Paren, fmt.Sprintf("Expected %v %s but got %d", arity, noun, len(arguments)))
+	}
+
+	return function, arguments, nil
+}
+
+// call calls function, reporting the arguments a native function rejects at
+// paren.
+func (i *Interpreter) call(paren lexer.Token, function Callable, arguments []any) (any, error) {
+	value, err := function.call(i, arguments)
+	if message, ok := err.(argumentError); ok {
+		return nil, runtimeError(paren, string(message))
+	}
+
+	return value, err
+}
+
+// placeNamedArguments puts named arguments in the positions of the
+// parameters they name, after the positional ones. Parameters skipped over
+// are left missing and must have a default value.
+func placeNamedArguments(function Callable, arguments []any, names []lexer.Token, values []any) ([]any, error) {
+	target, ok := function.(parameterized)
+	if !ok {
+		return nil, runtimeError(names[0], "Only functions and classes defined in Lox take named arguments")
+	}
+	parameters := target.parameters()
+
+	for j, name := range names {
+		position := slices.IndexFunc(parameters, func(parameter lexer.Token) bool {
+			return parameter.Lexeme == name.Lexeme
+		})
+		if position < 0 {
+			return nil, runtimeError(name, fmt.Sprintf("No parameter named '%s'", name.Lexeme))
+		}
+
+		for len(arguments) <= position {
+			arguments = append(arguments, missing)
+		}
+		if arguments[position] != missing {
+			return nil, runtimeError(name, fmt.Sprintf("Parameter '%s' already has an argument", name.Lexeme))
+		}
+		arguments[position] = values[j]
+	}
+
+	required := min(function.arity().min, len(arguments))
+	for position := range required {
+		if arguments[position] == missing {
+			return nil, runtimeError(names[0], fmt.Sprintf("Missing argument for parameter '%s'", parameters[position].Lexeme))
+		}
+	}
+
+	return arguments, nil
+}
+
+func (i *Interpreter) evaluateGet(expression ast.Get) (any, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil && expression.Optional {
+		return nil, errSkipChain
+	}
+
+	if object, ok := object.(Object); ok {
+		return object.get(expression.Name)
+	}
+
+	return nil, runtimeError(expression.Name, "Only objects have properties")
+}
+
+func (i *Interpreter) evaluateSet(expression ast.Set) (any, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, runtimeError(expression.Name, "Only instances have fields")
+	}
+
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, err
+	}
+	instance.set(expression.Name, value)
+
+	return value, nil
+}
+
+func (i *Interpreter) evaluateThis(expression ast.This) (any, error) {
+	return i.lookUpVariable(expression.Keyword)
+}
+
+func (i *Interpreter) evaluateSuper(expression ast.Super) (any, error) {
+	distance, _ := i.env.module.depth(expression.Keyword)
+	superclass := i.env.getAt(distance, "super").(*LoxClass)
+	// "this" is always bound one environment nearer than "super".
+	object := i.env.getAt(distance-1, "this").(*LoxInstance)
+
+	method := superclass.findMethod(expression.Method.Lexeme)
+	if method == nil {
+		return nil, runtimeError(expression.Method, fmt.Sprintf("Undefined property '%s'", expression.Method.Lexeme))
+	}
+
+	return method.bind(object), nil
+}
+
+func (i *Interpreter) evaluateList(expression ast.List) (any, error) {
+	elements := make([]any, 0, len(expression.Elements))
+	for _, element := range expression.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+
+	return NewLoxList(elements), nil
+}
+
+func (i *Interpreter) evaluateMap(expression ast.Map) (any, error) {
+	m := NewLoxMap()
+	for j := range expression.Keys {
+		key, err := i.evaluate(expression.Keys[j])
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(expression.Values[j])
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.setIndex(expression.Brace, key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (i *Interpreter) evaluateLambda(expression ast.Lambda) (any, error) {
+	return NewFunction(expression.Declaration, i.env, false), nil
+}
+
+// evaluateAwait waits for a promise to settle. Other values are awaited as
+// they are, without suspending.
+func (i *Interpreter) evaluateAwait(expression ast.Await) (any, error) {
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	promise, ok := value.(*Promise)
+	if !ok {
+		return value, nil
+	}
+
+	return i.async.await(expression.Keyword, promise)
+}
+
+func (i *Interpreter) evaluateInterpolation(expression ast.Interpolation) (any, error) {
+	var s strings.Builder
+	for _, part := range expression.Parts {
+		value, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := i.str(value)
+		if err != nil {
+			return nil, err
+		}
+		s.WriteString(text)
+	}
+
+	return s.String(), nil
+}
+
+// errSkipChain is returned by an optional link of a chain that finds nil, and
+// passes through the links after it up to the OptionalChain.
+var errSkipChain = errors.New("optional chain skipped")
+
+func (i *Interpreter) evaluateOptionalChain(expression ast.OptionalChain) (any, error) {
+	value, err := i.evaluate(expression.Expr)
+	if err == errSkipChain {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+func (i *Interpreter) evaluateIndex(expression ast.Index) (any, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil && expression.Optional {
+		return nil, errSkipChain
+	}
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	indexable, err := i.indexable(expression.Bracket, object)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexable.getIndex(expression.Bracket, index)
+}
+
+func (i *Interpreter) evaluateSetIndex(expression ast.SetIndex) (any, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	indexable, err := i.indexable(expression.Bracket, object)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, err
 	}
-	function := callee.(Callable)
-	if function.arity() != len(arguments) {
-		runtimeError(expression.Paren, fmt.Sprintf("Expected %d, arguments got %d", function.arity(), len(arguments)))
+	if err := indexable.setIndex(expression.Bracket, index, value); err != nil {
+		return nil, err
 	}
 
-	return function.call(i, arguments)
+	return value, nil
 }
@@ -0,0 +1,249 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/ast"
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// errGeneratorClosed unwinds the body of a generator closed before it
+// finished, running its finally blocks on the way out.
+var errGeneratorClosed = errors.New("generator closed")
+
+// Generator is what calling a generator function returns. Its body runs on a
+// goroutine of its own that takes turns with the code consuming it: resuming
+// the generator blocks until the body yields or finishes, so the two never
+// run at the same time. Values are produced one at a time, as they are asked
+// for.
+type Generator struct {
+	name        lexer.Token
+	interpreter *Interpreter
+	body        ast.Block
+
+	// resume tells a suspended body to continue, or to unwind when false.
+	resume  chan bool
+	results chan generatorResult
+
+	// running is held while the generator is being resumed or closed, so
+	// goroutines sharing the generator take turns. The body resuming its own
+	// generator would wait for itself forever, which lock reports instead.
+	running sync.Mutex
+
+	started  bool
+	finished bool
+
+	// hasNext runs the body ahead to its next yield and keeps the value here
+	// until next asks for it.
+	buffered bool
+	value    any
+}
+
+type generatorResult struct {
+	value    any
+	finished bool
+	err      error
+}
+
+func NewGenerator(name lexer.Token, interpreter *Interpreter, body ast.Block, env *Environment) *Generator {
+	generator := &Generator{
+		name:    name,
+		body:    body,
+		resume:  make(chan bool),
+		results: make(chan generatorResult),
+	}
+
+	generator.interpreter = interpreter.fork(env)
+	generator.interpreter.generator = generator
+
+	return generator
+}
+
+func (g *Generator) run() {
+	err := g.interpreter.executeBlock(g.body, g.interpreter.env)
+	if _, returned := err.(*returnSignal); returned || err == errGeneratorClosed {
+		err = nil
+	}
+
+	g.results <- generatorResult{finished: true, err: err}
+}
+
+// advance runs the body up to its next yield, or to its end.
+func (g *Generator) advance() error {
+	if !g.started {
+		g.started = true
+		go g.run()
+	} else {
+		g.resume <- true
+	}
+	result := <-g.results
+
+	if result.finished {
+		g.finished = true
+		return result.err
+	}
+
+	g.value, g.buffered = result.value, true
+	return nil
+}
+
+// yield hands value to the consumer and suspends the body until it is
+// resumed. It is called on the generator's own goroutine.
+func (g *Generator) yield(value any) error {
+	g.results <- generatorResult{value: value}
+	if !<-g.resume {
+		return errGeneratorClosed
+	}
+
+	return nil
+}
+
+// lock takes the running lock for caller, the interpreter resuming or
+// closing the generator, waiting while another goroutine has it.
+func (g *Generator) lock(caller *Interpreter) error {
+	if caller.generator == g {
+		return runtimeError(g.name, "Generator is already running")
+	}
+
+	g.running.Lock()
+	return nil
+}
+
+func (g *Generator) hasNext(caller *Interpreter) (bool, error) {
+	if err := g.lock(caller); err != nil {
+		return false, err
+	}
+	defer g.running.Unlock()
+
+	return g.fill()
+}
+
+// fill makes sure the next value is buffered, unless the generator has
+// finished, and reports whether it is.
+func (g *Generator) fill() (bool, error) {
+	if !g.buffered && !g.finished {
+		if err := g.advance(); err != nil {
+			return false, err
+		}
+	}
+
+	return g.buffered, nil
+}
+
+// next returns the next value, or nil once the generator has finished.
+func (g *Generator) next(caller *Interpreter) (any, error) {
+	value, _, err := g.take(caller)
+	return value, err
+}
+
+// take returns the next value, and whether there was one, in a single turn,
+// so no other goroutine can take it between checking and taking.
+func (g *Generator) take(caller *Interpreter) (value any, ok bool, err error) {
+	if err := g.lock(caller); err != nil {
+		return nil, false, err
+	}
+	defer g.running.Unlock()
+
+	if more, err := g.fill(); !more {
+		return nil, false, err
+	}
+
+	value = g.value
+	g.buffered, g.value = false, nil
+	return value, true, nil
+}
+
+// close stops a suspended generator, running the finally blocks its body is
+// in, so that its goroutine ends. Loops close the generators they stop
+// iterating early.
+func (g *Generator) close(caller *Interpreter) error {
+	if err := g.lock(caller); err != nil {
+		return err
+	}
+	defer g.running.Unlock()
+
+	if !g.started || g.finished {
+		g.finished = true
+		return nil
+	}
+
+	g.buffered, g.value = false, nil
+	for {
+		// A finally block may yield again, which is answered with another
+		// request to unwind.
+		g.resume <- false
+		if result := <-g.results; result.finished {
+			g.finished = true
+			return result.err
+		}
+	}
+}
+
+func (g *Generator) iterator(interpreter *Interpreter, token lexer.Token) Iterator {
+	return &generatorIterator{generator: g, caller: interpreter}
+}
+
+func (g *Generator) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "next":
+		return NewNativeFunction("next", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return g.next(interpreter)
+		}), nil
+	case "hasNext":
+		return NewNativeFunction("hasNext", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return g.hasNext(interpreter)
+		}), nil
+	case "close":
+		return NewNativeFunction("close", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return nil, g.close(interpreter)
+		}), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (g *Generator) String() string {
+	if g.name.TokenType != lexer.IDENTIFIER {
+		return "<generator>"
+	}
+
+	return fmt.Sprintf("<generator %s>", g.name.Lexeme)
+}
+
+// generatorIterator is a loop over a generator, resuming it for the
+// interpreter running the loop. Like channelIterator it takes values ahead
+// in hasNext, since goroutines looping over the same generator would
+// otherwise take values from under each other between hasNext and next.
+type generatorIterator struct {
+	generator *Generator
+	caller    *Interpreter
+	buffered  bool
+	value     any
+}
+
+func (it *generatorIterator) hasNext() (bool, error) {
+	if !it.buffered {
+		var err error
+		if it.value, it.buffered, err = it.generator.take(it.caller); err != nil {
+			return false, err
+		}
+	}
+
+	return it.buffered, nil
+}
+
+func (it *generatorIterator) next() (any, error) {
+	if more, err := it.hasNext(); !more {
+		return nil, err
+	}
+
+	value := it.value
+	it.buffered, it.value = false, nil
+	return value, nil
+}
+
+func (it *generatorIterator) close() error {
+	return it.generator.close(it.caller)
+}
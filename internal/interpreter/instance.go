@@ -0,0 +1,54 @@
+package interpreter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Object is implemented by runtime values that expose properties through
+// dot access.
+type Object interface {
+	get(name lexer.Token) (any, error)
+}
+
+type LoxInstance struct {
+	class *LoxClass
+
+	mu     sync.RWMutex
+	fields map[string]any
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		class:  class,
+		fields: make(map[string]any),
+	}
+}
+
+func (l *LoxInstance) get(name lexer.Token) (any, error) {
+	l.mu.RLock()
+	value, ok := l.fields[name.Lexeme]
+	l.mu.RUnlock()
+	if ok {
+		return value, nil
+	}
+
+	if method := l.class.findMethod(name.Lexeme); method != nil {
+		return method.bind(l), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (l *LoxInstance) set(name lexer.Token, value any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fields[name.Lexeme] = value
+}
+
+func (l *LoxInstance) String() string {
+	return fmt.Sprintf("<%s instance>", l.class.name)
+}
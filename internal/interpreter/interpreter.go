@@ -2,39 +2,117 @@ package interpreter
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
 
 	"github.com/umed-hotamov/golox/internal/ast"
 	"github.com/umed-hotamov/golox/internal/lexer"
 )
 
+// Interpreter runs one thread of Lox code. Generators and spawned goroutines
+// run on forks of the interpreter that started them: each fork has its own
+// current environment, and all of them share the state below.
 type Interpreter struct {
-	env     *Environment
-	globals *Environment
-	locals  map[ast.Expr]int
+	env      *Environment
+	builtins *Environment
+
+	*shared
+	importing []string
+	loader    ModuleLoader
+
+	// generator is the generator whose body this interpreter runs, if any.
+	generator *Generator
+	// async is the async function call whose body this interpreter runs, if
+	// any.
+	async *asyncCall
+}
+
+type shared struct {
+	mu      sync.Mutex
+	modules map[string]*Module
+
+	goroutines goroutines
+
+	loop *EventLoop
 }
 
 func NewInterpreter() *Interpreter {
-	globals := NewEnvironment()
+	builtins := NewEnvironment()
 
-	globals.define("clock", new(Clock))
+	builtins.bind("clock", new(Clock))
+	builtins.bind("Channel", new(ChannelConstructor))
+	builtins.bind("Promise", new(PromiseConstructor))
+	builtins.bind("setTimeout", NewNativeFunction("setTimeout", 2, setTimer(false)))
+	builtins.bind("setInterval", NewNativeFunction("setInterval", 2, setTimer(true)))
+	builtins.bind("clearTimeout", NewNativeFunction("clearTimeout", 1, clearTimer))
+	builtins.bind("clearInterval", NewNativeFunction("clearInterval", 1, clearTimer))
+
+	state := &shared{
+		modules: make(map[string]*Module),
+		// The goroutine calling Interpret runs Lox code too.
+		goroutines: goroutines{live: 1},
+	}
+	state.loop = NewEventLoop(&state.goroutines)
 
 	return &Interpreter{
-		env:     globals,
-		globals: globals,
-		locals:  make(map[ast.Expr]int),
+		env:      NewModule("", builtins).globals,
+		builtins: builtins,
+		shared:   state,
 	}
 }
 
-func (i *Interpreter) Interpret(statements []ast.Stmt) {
-	defer errorRecovery()
+// fork returns an interpreter sharing i's state that runs code separately
+// from i, starting in env.
+func (i *Interpreter) fork(env *Environment) *Interpreter {
+	forked := *i
+	forked.env = env
+	forked.importing = slices.Clone(i.importing)
+	forked.generator = nil
+	forked.async = nil
+
+	return &forked
+}
 
+// Interpret runs statements, then the event loop until no timers or promise
+// callbacks are left, waiting for the goroutines the script spawned as well.
+// If a statement or a timer callback fails, it returns right away.
+func (i *Interpreter) Interpret(statements []ast.Stmt) {
 	for _, stmt := range statements {
-		i.execute(stmt)
+		if err := i.execute(stmt); err != nil {
+			fmt.Println(err)
+			return
+		}
 	}
+
+	// Spawned goroutines may give the loop more work after it ran out.
+	for {
+		if err := i.loop.run(i); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if !i.loop.wait() {
+			return
+		}
+	}
+}
+
+// SetTimeSource sets the clock the event loop runs timers by, such as a
+// VirtualTime in tests.
+func (i *Interpreter) SetTimeSource(time TimeSource) {
+	i.loop.mu.Lock()
+	defer i.loop.mu.Unlock()
+
+	i.loop.time = time
 }
 
-func (i *Interpreter) Resolve(expression ast.Expr, depth int) {
-	i.locals[expression] = depth
+// Resolve records the scope depth of a local variable reference in the module
+// being compiled. References are keyed by their name token, since expression
+// nodes holding slices (calls, for instance) can't be used as map keys.
+func (i *Interpreter) Resolve(name lexer.Token, depth int) {
+	i.env.module.resolve(name, depth)
 }
 
 func isTruthy(value any) bool {
@@ -43,7 +121,7 @@ func isTruthy(value any) bool {
 	}
 
 	if isNumber(value) {
-		return value.(float64) != 0
+		return toFloat(value) != 0
 	}
 	if isString(value) {
 		return value.(string) != ""
@@ -62,8 +140,12 @@ func isString(value any) bool {
 }
 
 func isNumber(value any) bool {
-	_, ok := value.(float64)
-	return ok
+	switch value.(type) {
+	case int64, float64:
+		return true
+	}
+
+	return false
 }
 
 func isBool(value any) bool {
@@ -71,30 +153,111 @@ func isBool(value any) bool {
 	return ok
 }
 
+// isEqual compares nil, booleans, numbers and strings by value and every
+// other runtime value (instances, lists, functions...) by identity. The ==
+// operator goes through Interpreter.equal, which lets instances define
+// __eq__.
 func isEqual(left any, right any) bool {
-	if left == nil && right == nil {
-		return true
+	if isNumber(left) && isNumber(right) {
+		return numbersEqual(left, right)
 	}
-	if left == nil {
-		return false
+
+	return left == right
+}
+
+// hashKey returns the Go map key a value is stored under when it is used as a
+// map key. Only values with value semantics are hashable; ok reports whether
+// value is one of them.
+func hashKey(value any) (key any, ok bool) {
+	switch value := value.(type) {
+	case nil, bool, string, int64:
+		return value, true
+	case float64:
+		// NaN is never equal to itself, so it could never be looked up again.
+		if math.IsNaN(value) {
+			return nil, false
+		}
+		// Equal numbers must share a key, so integral floats hash as integers.
+		if integer, ok := floatToInteger(value); ok {
+			return integer, true
+		}
+		return value, true
 	}
 
-	if isString(left) && isString(right) {
-		return left.(string) == right.(string)
+	return nil, false
+}
+
+// stringify converts a value to the text print shows for it.
+func stringify(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		// Plain notation reads better for the magnitudes scripts usually deal
+		// with; exponents are kept for very large and very small numbers.
+		if abs := math.Abs(value); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			return strconv.FormatFloat(value, 'g', -1, 64)
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64)
 	}
-	if isNumber(left) && isNumber(right) {
-		return left.(float64) == right.(float64)
+
+	return fmt.Sprint(value)
+}
+
+// repr is like stringify, but quotes strings so they stand out inside
+// collections.
+func repr(value any) string {
+	if isString(value) {
+		return fmt.Sprintf("%q", value)
 	}
 
-	return false
+	return stringify(value)
 }
 
-func runtimeError(token lexer.Token, message string) {
-	panic(fmt.Sprintf("[line: %d , at %s] Error: %s\n", token.Line, token.Lexeme, message))
+// printing holds the containers being printed, so one that contains itself
+// is shown abbreviated rather than printed forever.
+type printing map[any]bool
+
+// repr is like the function repr, but formats the containers in value with
+// seen.
+func (seen printing) repr(value any) (string, error) {
+	switch value := value.(type) {
+	case *LoxList:
+		return value.format(seen, seen.repr)
+	case *LoxMap:
+		return value.format(seen, seen.repr)
+	}
+
+	return repr(value), nil
+}
+
+func checkNumberOperand(operator lexer.Token, operand any) error {
+	if !isNumber(operand) {
+		return runtimeError(operator, "Operand must be a number")
+	}
+
+	return nil
+}
+
+func checkNumberOperands(operator lexer.Token, left any, right any) error {
+	if !isNumber(left) || !isNumber(right) {
+		return runtimeError(operator, "Operands must be numbers")
+	}
+
+	return nil
 }
 
-func errorRecovery() {
-	if err := recover(); err != nil {
-		fmt.Print(err)
+func checkIntegerOperands(operator lexer.Token, operands ...any) error {
+	for _, operand := range operands {
+		if !isInteger(operand) {
+			if len(operands) == 1 {
+				return runtimeError(operator, "Operand must be an integer")
+			}
+			return runtimeError(operator, "Operands must be integers")
+		}
 	}
+
+	return nil
 }
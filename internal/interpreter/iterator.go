@@ -0,0 +1,134 @@
+package interpreter
+
+import (
+	"slices"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Iterator produces the values a for-in loop runs over.
+type Iterator interface {
+	hasNext() (bool, error)
+	next() (any, error)
+}
+
+// closer is implemented by iterators that hold on to resources until they
+// are exhausted, so loops stopping early must release them.
+type closer interface {
+	close() error
+}
+
+// Iterable is implemented by runtime values that for-in can loop over
+// directly. Strings and instances are handled by Interpreter.iterate. The
+// iterator reports errors at token.
+type Iterable interface {
+	iterator(interpreter *Interpreter, token lexer.Token) Iterator
+}
+
+// iterate returns an iterator over value. Instances take part through the
+// iterator protocol: an iter() method returning something iterable, or
+// hasNext() and next() methods making the instance its own iterator.
+func (i *Interpreter) iterate(token lexer.Token, value any) (Iterator, error) {
+	switch value := value.(type) {
+	case Iterable:
+		return value.iterator(i, token), nil
+	case string:
+		characters := make([]any, 0, len(value))
+		for _, r := range value {
+			characters = append(characters, string(r))
+		}
+		return &sliceIterator{values: characters}, nil
+	case *LoxInstance:
+		if value.class.findMethod("iter") != nil {
+			iterable, err := callMethod(i, token, value, "iter")
+			if err != nil {
+				return nil, err
+			}
+			if iterable != value {
+				return i.iterate(token, iterable)
+			}
+		}
+
+		if value.class.findMethod("hasNext") != nil && value.class.findMethod("next") != nil {
+			return &instanceIterator{interpreter: i, token: token, instance: value}, nil
+		}
+	}
+
+	return nil, runtimeError(token, "Can only loop over lists, maps, strings and iterable instances")
+}
+
+// callMethod calls one of the iterator protocol methods, which take no
+// arguments, reporting failures at token.
+func callMethod(interpreter *Interpreter, token lexer.Token, instance *LoxInstance, name string) (any, error) {
+	method := instance.class.findMethod(name)
+	if !method.arity().accepts(0) {
+		return nil, runtimeError(token, "Method '"+name+"' of an iterator can't take arguments")
+	}
+
+	return method.bind(instance).call(interpreter, nil)
+}
+
+// listIterator walks a list by position, so elements pushed during the loop
+// are visited too.
+type listIterator struct {
+	list  *LoxList
+	index int
+}
+
+func (l *listIterator) hasNext() (bool, error) {
+	return l.index < l.list.len(), nil
+}
+
+// next returns nil if another goroutine shrank the list since hasNext.
+func (l *listIterator) next() (any, error) {
+	value, _ := l.list.at(l.index)
+	l.index++
+	return value, nil
+}
+
+type sliceIterator struct {
+	values []any
+	index  int
+}
+
+func (s *sliceIterator) hasNext() (bool, error) {
+	return s.index < len(s.values), nil
+}
+
+func (s *sliceIterator) next() (any, error) {
+	value := s.values[s.index]
+	s.index++
+	return value, nil
+}
+
+type instanceIterator struct {
+	interpreter *Interpreter
+	token       lexer.Token
+	instance    *LoxInstance
+}
+
+func (it *instanceIterator) hasNext() (bool, error) {
+	more, err := callMethod(it.interpreter, it.token, it.instance, "hasNext")
+	if err != nil {
+		return false, err
+	}
+
+	return isTruthy(more), nil
+}
+
+func (it *instanceIterator) next() (any, error) {
+	return callMethod(it.interpreter, it.token, it.instance, "next")
+}
+
+func (l *LoxList) iterator(interpreter *Interpreter, token lexer.Token) Iterator {
+	return &listIterator{list: l}
+}
+
+// Maps are iterated over a snapshot of their keys, so deleting entries during
+// the loop is safe.
+func (m *LoxMap) iterator(interpreter *Interpreter, token lexer.Token) Iterator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return &sliceIterator{values: slices.Clone(m.keys)}
+}
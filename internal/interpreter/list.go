@@ -0,0 +1,259 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Indexable is implemented by runtime values that support subscripting.
+type Indexable interface {
+	getIndex(token lexer.Token, index any) (any, error)
+	setIndex(token lexer.Token, index any, value any) error
+}
+
+// LoxList is a growable sequence. Lists may be shared between goroutines,
+// so every access to elements holds mu.
+type LoxList struct {
+	mu       sync.RWMutex
+	elements []any
+}
+
+func NewLoxList(elements []any) *LoxList {
+	return &LoxList{
+		elements: elements,
+	}
+}
+
+func (l *LoxList) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "len":
+		return NewNativeFunction("len", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return int64(l.len()), nil
+		}), nil
+	case "push":
+		return NewNativeFunction("push", 1, func(interpreter *Interpreter, arguments []any) (any, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.elements = append(l.elements, arguments[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return NewNativeFunction("pop", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if len(l.elements) == 0 {
+				return nil, runtimeError(name, "Can't pop from an empty list")
+			}
+
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last, nil
+		}), nil
+	case "insert":
+		return NewNativeFunction("insert", 2, func(interpreter *Interpreter, arguments []any) (any, error) {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			// Inserting at len appends, so it is a valid position here.
+			position, err := toIndex(name, arguments[0], len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+			if position < 0 || position > len(l.elements) {
+				return nil, runtimeError(name, "Index out of range")
+			}
+
+			l.elements = append(l.elements, nil)
+			copy(l.elements[position+1:], l.elements[position:])
+			l.elements[position] = arguments[1]
+			return nil, nil
+		}), nil
+	case "slice":
+		return NewNativeFunction("slice", 2, func(interpreter *Interpreter, arguments []any) (any, error) {
+			l.mu.RLock()
+			defer l.mu.RUnlock()
+
+			start, err := clampIndex(name, arguments[0], len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+			end, err := clampIndex(name, arguments[1], len(l.elements))
+			if err != nil {
+				return nil, err
+			}
+			if end < start {
+				end = start
+			}
+
+			elements := make([]any, end-start)
+			copy(elements, l.elements[start:end])
+			return NewLoxList(elements), nil
+		}), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (l *LoxList) getIndex(token lexer.Token, index any) (any, error) {
+	if r, ok := index.(*LoxRange); ok {
+		return l.slice(token, r)
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i, err := normalizeIndex(token, index, len(l.elements))
+	if err != nil {
+		return nil, err
+	}
+
+	return l.elements[i], nil
+}
+
+func (l *LoxList) setIndex(token lexer.Token, index any, value any) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i, err := normalizeIndex(token, index, len(l.elements))
+	if err != nil {
+		return err
+	}
+
+	l.elements[i] = value
+	return nil
+}
+
+// slice returns a new list of the elements at the positions in r. Unlike
+// plain indices, positions in a range don't count from the end.
+func (l *LoxList) slice(token lexer.Token, r *LoxRange) (any, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var elements []any
+
+	for positions := r.walk(); !positions.done; {
+		i := positions.advance()
+		if i < 0 || i >= int64(len(l.elements)) {
+			return nil, runtimeError(token, "Index out of range")
+		}
+
+		elements = append(elements, l.elements[i])
+	}
+
+	return NewLoxList(elements), nil
+}
+
+// contains compares elements with value using __eq__ where instances
+// define it.
+func (l *LoxList) contains(interpreter *Interpreter, token lexer.Token, value any) (bool, error) {
+	for _, element := range l.snapshot() {
+		equal, err := interpreter.equal(token, value, element)
+		if err != nil {
+			return false, err
+		}
+		if equal {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (l *LoxList) len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.elements)
+}
+
+// at returns the element at position i and whether there is one.
+func (l *LoxList) at(i int) (any, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if i < 0 || i >= len(l.elements) {
+		return nil, false
+	}
+
+	return l.elements[i], true
+}
+
+// snapshot returns a copy of the elements, for reading them without holding
+// the lock while other code runs.
+func (l *LoxList) snapshot() []any {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return slices.Clone(l.elements)
+}
+
+func (l *LoxList) String() string {
+	seen := printing{}
+	text, _ := l.format(seen, seen.repr)
+	return text
+}
+
+// format shows the list with its elements formatted by element, unless seen
+// holds it already.
+func (l *LoxList) format(seen printing, element func(any) (string, error)) (string, error) {
+	if seen[l] {
+		return "[...]", nil
+	}
+	seen[l] = true
+	defer delete(seen, l)
+
+	snapshot := l.snapshot()
+	elements := make([]string, len(snapshot))
+	for i, value := range snapshot {
+		text, err := element(value)
+		if err != nil {
+			return "", err
+		}
+		elements[i] = text
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]", nil
+}
+
+// toIndex converts a Lox number to an integer index, counting negative
+// indices from the end of a sequence of the given length.
+func toIndex(token lexer.Token, index any, length int) (int, error) {
+	number, ok := toInteger(index)
+	if !ok {
+		return 0, runtimeError(token, "Index must be an integer")
+	}
+
+	i := int(number)
+	if i < 0 {
+		i += length
+	}
+
+	return i, nil
+}
+
+func normalizeIndex(token lexer.Token, index any, length int) (int, error) {
+	i, err := toIndex(token, index, length)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || i >= length {
+		return 0, runtimeError(token, "Index out of range")
+	}
+
+	return i, nil
+}
+
+func clampIndex(token lexer.Token, index any, length int) (int, error) {
+	i, err := toIndex(token, index, length)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(0, min(i, length)), nil
+}
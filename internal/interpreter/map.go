@@ -0,0 +1,178 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// LoxMap is a dictionary keyed by hashable values. Keys are kept in insertion
+// order so iteration is deterministic. Every access to entries and keys
+// holds mu.
+type LoxMap struct {
+	mu      sync.RWMutex
+	entries map[any]any
+	keys    []any
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		entries: make(map[any]any),
+	}
+}
+
+func (m *LoxMap) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "len":
+		return NewNativeFunction("len", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+
+			return int64(len(m.keys)), nil
+		}), nil
+	case "has":
+		return NewNativeFunction("has", 1, func(interpreter *Interpreter, arguments []any) (any, error) {
+			return m.contains(interpreter, name, arguments[0])
+		}), nil
+	case "delete":
+		return NewNativeFunction("delete", 1, func(interpreter *Interpreter, arguments []any) (any, error) {
+			key, err := m.key(name, arguments[0])
+			if err != nil {
+				return nil, err
+			}
+
+			return m.delete(key), nil
+		}), nil
+	case "keys":
+		return NewNativeFunction("keys", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+
+			return NewLoxList(slices.Clone(m.keys)), nil
+		}), nil
+	case "values":
+		return NewNativeFunction("values", 0, func(interpreter *Interpreter, arguments []any) (any, error) {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+
+			values := make([]any, 0, len(m.keys))
+			for _, key := range m.keys {
+				values = append(values, m.entries[key])
+			}
+			return NewLoxList(values), nil
+		}), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (m *LoxMap) getIndex(token lexer.Token, index any) (any, error) {
+	key, err := m.key(token, index)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.entries[key], nil
+}
+
+func (m *LoxMap) setIndex(token lexer.Token, index any, value any) error {
+	key, err := m.key(token, index)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.entries[key] = value
+	return nil
+}
+
+// delete removes key from the map and returns the value it held.
+func (m *LoxMap) delete(key any) any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+
+	delete(m.entries, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k any) bool {
+		return k == key
+	})
+
+	return value
+}
+
+func (m *LoxMap) key(token lexer.Token, value any) (any, error) {
+	key, ok := hashKey(value)
+	if !ok {
+		return nil, runtimeError(token, fmt.Sprintf("Unhashable map key %s", repr(value)))
+	}
+
+	return key, nil
+}
+
+func (m *LoxMap) contains(interpreter *Interpreter, token lexer.Token, value any) (bool, error) {
+	key, err := m.key(token, value)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.entries[key]
+	return ok, nil
+}
+
+func (m *LoxMap) String() string {
+	seen := printing{}
+	text, _ := m.format(seen, seen.repr)
+	return text
+}
+
+// format shows the map with its keys and values formatted by element, unless
+// seen holds it already.
+func (m *LoxMap) format(seen printing, element func(any) (string, error)) (string, error) {
+	if seen[m] {
+		return "{...}", nil
+	}
+	seen[m] = true
+	defer delete(seen, m)
+
+	m.mu.RLock()
+	keys := slices.Clone(m.keys)
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = m.entries[key]
+	}
+	m.mu.RUnlock()
+
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		keyText, err := element(key)
+		if err != nil {
+			return "", err
+		}
+		valueText, err := element(values[i])
+		if err != nil {
+			return "", err
+		}
+		entries[i] = keyText + ": " + valueText
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}", nil
+}
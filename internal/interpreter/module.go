@@ -0,0 +1,191 @@
+package interpreter
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/ast"
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// ModuleLoader compiles imported files. It lives outside the interpreter
+// because compiling needs the resolver, which depends on this package.
+type ModuleLoader interface {
+	// Load reads the file at path and returns its statements, resolved
+	// against interpreter, which is set up to run them.
+	Load(interpreter *Interpreter, path string) ([]ast.Stmt, error)
+}
+
+// Module is the execution context of one source file: the environment
+// holding its top-level definitions and the scope depths of its local
+// variables. Every environment created while running the file points back to
+// it, so functions keep using their own module's globals wherever they're
+// called from. Imported modules are also the namespace objects scripts see.
+type Module struct {
+	path    string
+	globals *Environment
+
+	// locals is written while the module is compiled, which in the REPL
+	// happens while goroutines spawned earlier may be running its code.
+	mu     sync.RWMutex
+	locals map[lexer.Token]int
+}
+
+func NewModule(path string, builtins *Environment) *Module {
+	module := &Module{
+		path:   path,
+		locals: make(map[lexer.Token]int),
+	}
+
+	module.globals = NewEnclosingEnvironment(builtins)
+	module.globals.module = module
+
+	return module
+}
+
+func (m *Module) resolve(name lexer.Token, depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.locals[name] = depth
+}
+
+// depth returns the scope depth recorded for a local variable reference; ok
+// is false for globals.
+func (m *Module) depth(name lexer.Token) (depth int, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	depth, ok = m.locals[name]
+	return depth, ok
+}
+
+func (m *Module) get(name lexer.Token) (any, error) {
+	if value, ok := m.globals.lookup(name.Lexeme); ok {
+		return value, nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Module '%s' has no member '%s'", m.name(), name.Lexeme))
+}
+
+func (m *Module) name() string {
+	return strings.TrimSuffix(filepath.Base(m.path), filepath.Ext(m.path))
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.name())
+}
+
+// SetModuleLoader enables import statements, which fail until a loader is set.
+func (i *Interpreter) SetModuleLoader(loader ModuleLoader) {
+	i.loader = loader
+}
+
+// SetScriptPath tells the interpreter which file the main program was read
+// from, so its imports are found relative to it.
+func (i *Interpreter) SetScriptPath(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	i.env.module.path = path
+	i.importing = append(i.importing, path)
+}
+
+func (i *Interpreter) executeImport(statement ast.Import) error {
+	module, err := i.importModule(statement.Path)
+	if err != nil {
+		return err
+	}
+
+	if statement.Alias != nil {
+		return i.env.define(*statement.Alias, module)
+	}
+
+	for _, name := range statement.Names {
+		value, err := module.get(name)
+		if err != nil {
+			return err
+		}
+
+		// Constants stay constant in the importing file.
+		if module.globals.isConstant(name.Lexeme) {
+			err = i.env.defineConstant(name, value)
+		} else {
+			err = i.env.define(name, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importModule returns the module at the path token's location, relative to
+// the importing file, running it on first use.
+func (i *Interpreter) importModule(token lexer.Token) (*Module, error) {
+	path := token.Literal.(string)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(i.env.module.path), path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	i.mu.Lock()
+	module, ok := i.modules[path]
+	i.mu.Unlock()
+	if ok {
+		return module, nil
+	}
+
+	if slices.Contains(i.importing, path) {
+		cycle := append(slices.Clone(i.importing[slices.Index(i.importing, path):]), path)
+		return nil, runtimeError(token, fmt.Sprintf("Import cycle: %s", strings.Join(cycle, " -> ")))
+	}
+	if i.loader == nil {
+		return nil, runtimeError(token, "Imports are not supported here")
+	}
+
+	// Two goroutines importing the same module for the first time at once
+	// both run it; the last one to finish is cached.
+	module = NewModule(path, i.builtins)
+
+	i.importing = append(i.importing, path)
+	previous := i.env
+	i.env = module.globals
+
+	err := i.runModule(token, path)
+
+	i.env = previous
+	i.importing = i.importing[:len(i.importing)-1]
+
+	if err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	i.modules[path] = module
+	i.mu.Unlock()
+
+	return module, nil
+}
+
+func (i *Interpreter) runModule(token lexer.Token, path string) error {
+	statements, err := i.loader.Load(i, path)
+	if err != nil {
+		return runtimeError(token, fmt.Sprintf("Can't import module: %v", err))
+	}
+
+	for _, stmt := range statements {
+		if err := i.execute(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
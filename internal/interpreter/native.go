@@ -1,14 +1,45 @@
 package interpreter
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Clock struct {
 }
 
-func (c Clock) arity() int {
-	return 0
+func (c Clock) arity() arityRange {
+	return exactly(0)
 }
 
-func (c Clock) call(interpreter *Interpreter, arguments []any) any {
-	return float64(time.Now().Second())
+func (c Clock) call(interpreter *Interpreter, arguments []any) (any, error) {
+	return float64(time.Now().Second()), nil
+}
+
+// NativeFunction is a callable implemented in Go, such as a method of a
+// built-in runtime type.
+type NativeFunction struct {
+	name     string
+	argCount int
+	function func(interpreter *Interpreter, arguments []any) (any, error)
+}
+
+func NewNativeFunction(name string, argCount int, function func(interpreter *Interpreter, arguments []any) (any, error)) *NativeFunction {
+	return &NativeFunction{
+		name:     name,
+		argCount: argCount,
+		function: function,
+	}
+}
+
+func (n *NativeFunction) arity() arityRange {
+	return exactly(n.argCount)
+}
+
+func (n *NativeFunction) call(interpreter *Interpreter, arguments []any) (any, error) {
+	return n.function(interpreter, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
 }
@@ -0,0 +1,243 @@
+package interpreter
+
+import (
+	"math"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Numbers are int64 when written without a fraction or exponent and float64
+// otherwise. Arithmetic on two integers stays integral, except for '/', which
+// always divides exactly; mixing in a float promotes the operation to float64.
+// Integer overflow is a runtime error rather than silently wrapping.
+
+// Bounds of the float64 values that convert to int64 exactly. 2^63 itself is
+// representable as a float64 but not as an int64.
+const (
+	minIntFloat = -(1 << 63)
+	maxIntFloat = 1 << 63
+)
+
+func isInteger(value any) bool {
+	_, ok := value.(int64)
+	return ok
+}
+
+func toFloat(value any) float64 {
+	if integer, ok := value.(int64); ok {
+		return float64(integer)
+	}
+
+	return value.(float64)
+}
+
+// toInteger converts integers and floats with an integral value to int64.
+func toInteger(value any) (int64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return value, true
+	case float64:
+		return floatToInteger(value)
+	}
+
+	return 0, false
+}
+
+// floatToInteger converts a float with an integral value to an int64.
+func floatToInteger(value float64) (int64, bool) {
+	if value != math.Trunc(value) || value < minIntFloat || value >= maxIntFloat {
+		return 0, false
+	}
+
+	return int64(value), true
+}
+
+// numbersEqual compares two numbers by value, exactly even when an integer is
+// compared to a float.
+func numbersEqual(left any, right any) bool {
+	if isInteger(left) && isInteger(right) {
+		return left.(int64) == right.(int64)
+	}
+	if isInteger(left) {
+		left, right = right, left
+	}
+	if isInteger(right) {
+		integer, ok := floatToInteger(left.(float64))
+		return ok && integer == right.(int64)
+	}
+
+	return left.(float64) == right.(float64)
+}
+
+func negate(operator lexer.Token, value any) (any, error) {
+	if integer, ok := value.(int64); ok {
+		if integer == math.MinInt64 {
+			return nil, runtimeError(operator, "Integer overflow")
+		}
+		return -integer, nil
+	}
+
+	return -value.(float64), nil
+}
+
+func compareNumbers(operator lexer.Token, left any, right any) bool {
+	if isInteger(left) && isInteger(right) {
+		a, b := left.(int64), right.(int64)
+		switch operator.TokenType {
+		case lexer.GREATER:
+			return a > b
+		case lexer.GREATER_EQUAL:
+			return a >= b
+		case lexer.LESS:
+			return a < b
+		case lexer.LESS_EQUAL:
+			return a <= b
+		}
+	}
+
+	a, b := toFloat(left), toFloat(right)
+	switch operator.TokenType {
+	case lexer.GREATER:
+		return a > b
+	case lexer.GREATER_EQUAL:
+		return a >= b
+	case lexer.LESS:
+		return a < b
+	case lexer.LESS_EQUAL:
+		return a <= b
+	}
+
+	return false
+}
+
+func arithmetic(operator lexer.Token, left any, right any) (any, error) {
+	if isInteger(left) && isInteger(right) {
+		return integerArithmetic(operator, left.(int64), right.(int64))
+	}
+
+	a, b := toFloat(left), toFloat(right)
+	switch operator.TokenType {
+	case lexer.PLUS:
+		return a + b, nil
+	case lexer.MINUS:
+		return a - b, nil
+	case lexer.STAR:
+		return a * b, nil
+	case lexer.SLASH:
+		return a / b, nil
+	case lexer.PERCENT:
+		return math.Mod(a, b), nil
+	case lexer.STAR_STAR:
+		return math.Pow(a, b), nil
+	case lexer.TILDE_SLASH:
+		if b == 0 {
+			return nil, runtimeError(operator, "Division by zero")
+		}
+
+		quotient, ok := floatToInteger(math.Trunc(a / b))
+		if !ok {
+			return nil, runtimeError(operator, "Integer division result out of range")
+		}
+		return quotient, nil
+	}
+
+	return nil, nil
+}
+
+func integerArithmetic(operator lexer.Token, a int64, b int64) (any, error) {
+	switch operator.TokenType {
+	case lexer.PLUS:
+		sum := a + b
+		if (sum^a)&(sum^b) < 0 {
+			return nil, runtimeError(operator, "Integer overflow")
+		}
+		return sum, nil
+	case lexer.MINUS:
+		difference := a - b
+		if (a^b)&(difference^a) < 0 {
+			return nil, runtimeError(operator, "Integer overflow")
+		}
+		return difference, nil
+	case lexer.STAR:
+		product, ok := multiplyExact(a, b)
+		if !ok {
+			return nil, runtimeError(operator, "Integer overflow")
+		}
+		return product, nil
+	case lexer.SLASH:
+		return float64(a) / float64(b), nil
+	case lexer.PERCENT:
+		if b == 0 {
+			return nil, runtimeError(operator, "Division by zero")
+		}
+		return a % b, nil
+	case lexer.TILDE_SLASH:
+		if b == 0 {
+			return nil, runtimeError(operator, "Division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return nil, runtimeError(operator, "Integer overflow")
+		}
+		return a / b, nil
+	case lexer.STAR_STAR:
+		if b < 0 {
+			return math.Pow(float64(a), float64(b)), nil
+		}
+		return integerPower(operator, a, b)
+	}
+
+	return nil, nil
+}
+
+func multiplyExact(a int64, b int64) (int64, bool) {
+	product := a * b
+	if a != 0 && (product/a != b || (a == -1 && b == math.MinInt64)) {
+		return 0, false
+	}
+
+	return product, true
+}
+
+// integerPower computes base ** exponent by repeated squaring, failing on
+// overflow.
+func integerPower(operator lexer.Token, base int64, exponent int64) (any, error) {
+	result := int64(1)
+	for exponent > 0 {
+		var ok bool
+		if exponent&1 == 1 {
+			if result, ok = multiplyExact(result, base); !ok {
+				return nil, runtimeError(operator, "Integer overflow")
+			}
+		}
+
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiplyExact(base, base); !ok {
+				return nil, runtimeError(operator, "Integer overflow")
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// bitwise applies a bitwise or shift operator to two integers. Shifting by 64
+// or more bits yields 0, or -1 when shifting a negative number right.
+func bitwise(operator lexer.Token, a int64, b int64) (any, error) {
+	switch operator.TokenType {
+	case lexer.AMPERSAND:
+		return a & b, nil
+	case lexer.PIPE:
+		return a | b, nil
+	case lexer.CARET:
+		return a ^ b, nil
+	}
+
+	if b < 0 {
+		return nil, runtimeError(operator, "Negative shift count")
+	}
+	if operator.TokenType == lexer.LESS_LESS {
+		return a << b, nil
+	}
+	return a >> b, nil
+}
@@ -0,0 +1,181 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Classes overload operators by defining special methods, which the
+// interpreter calls instead of applying the operator to instances.
+
+// operatorMethods names the methods overloading binary operators. Equality
+// is overloaded with __eq__, see Interpreter.equal.
+var operatorMethods = map[lexer.TokenType]string{
+	lexer.PLUS:          "__add__",
+	lexer.MINUS:         "__sub__",
+	lexer.STAR:          "__mul__",
+	lexer.SLASH:         "__div__",
+	lexer.PERCENT:       "__mod__",
+	lexer.STAR_STAR:     "__pow__",
+	lexer.LESS:          "__lt__",
+	lexer.LESS_EQUAL:    "__le__",
+	lexer.GREATER:       "__gt__",
+	lexer.GREATER_EQUAL: "__ge__",
+}
+
+// reflectedComparisons maps each comparison to the one that holds with the
+// operands swapped, so a < b can be answered by b.__gt__(a).
+var reflectedComparisons = map[lexer.TokenType]lexer.TokenType{
+	lexer.LESS:          lexer.GREATER,
+	lexer.LESS_EQUAL:    lexer.GREATER_EQUAL,
+	lexer.GREATER:       lexer.LESS,
+	lexer.GREATER_EQUAL: lexer.LESS_EQUAL,
+}
+
+// overload applies operator by calling the method the left operand's class
+// defines for it, or for comparisons the reflected method of the right
+// operand's class. ok is false when neither defines one.
+func (i *Interpreter) overload(operator lexer.Token, left any, right any) (result any, ok bool, err error) {
+	name, overloadable := operatorMethods[operator.TokenType]
+	if !overloadable {
+		return nil, false, nil
+	}
+
+	if instance := withMethod(left, name); instance != nil {
+		result, err = i.callSpecial(operator, instance, name, right)
+		return result, true, err
+	}
+
+	if reflected, ok := reflectedComparisons[operator.TokenType]; ok {
+		name = operatorMethods[reflected]
+		if instance := withMethod(right, name); instance != nil {
+			result, err = i.callSpecial(operator, instance, name, left)
+			return result, true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// equal compares two values with the __eq__ method of either operand, the
+// left one first, and like isEqual if neither defines it.
+func (i *Interpreter) equal(token lexer.Token, left any, right any) (bool, error) {
+	if instance := withMethod(left, "__eq__"); instance != nil {
+		result, err := i.callSpecial(token, instance, "__eq__", right)
+		return isTruthy(result), err
+	}
+	if instance := withMethod(right, "__eq__"); instance != nil {
+		result, err := i.callSpecial(token, instance, "__eq__", left)
+		return isTruthy(result), err
+	}
+
+	return isEqual(left, right), nil
+}
+
+// str converts value to the text print and string interpolation show, which
+// for an instance is what its __str__ method returns, also when the instance
+// is an element of a list or map.
+func (i *Interpreter) str(value any) (string, error) {
+	return i.format(printing{}, value, false)
+}
+
+// format is str for value, which is an element of a container if nested.
+// Like repr, it quotes nested strings.
+func (i *Interpreter) format(seen printing, value any, nested bool) (string, error) {
+	element := func(value any) (string, error) {
+		return i.format(seen, value, true)
+	}
+
+	switch value := value.(type) {
+	case *LoxList:
+		return value.format(seen, element)
+	case *LoxMap:
+		return value.format(seen, element)
+	case string:
+		if nested {
+			return repr(value), nil
+		}
+	}
+
+	instance := withMethod(value, "__str__")
+	if instance == nil {
+		return stringify(value), nil
+	}
+
+	name := instance.class.findMethod("__str__").declaration.Name
+	result, err := i.callSpecial(name, instance, "__str__")
+	if err != nil {
+		return "", err
+	}
+	text, ok := result.(string)
+	if !ok {
+		return "", runtimeError(name, "__str__ must return a string")
+	}
+
+	return text, nil
+}
+
+// callSpecial calls the special method name of instance, reporting a method
+// that can't take the arguments at token.
+func (i *Interpreter) callSpecial(token lexer.Token, instance *LoxInstance, name string, arguments ...any) (any, error) {
+	method := instance.class.findMethod(name)
+	if !method.arity().accepts(len(arguments)) {
+		noun := "arguments"
+		if len(arguments) == 1 {
+			noun = "argument"
+		}
+		return nil, runtimeError(token, fmt.Sprintf("Method '%s' must take %d %s", name, len(arguments), noun))
+	}
+
+	return method.bind(instance).call(i, arguments)
+}
+
+// withMethod returns value if it is an instance whose class defines the
+// method name, and nil otherwise.
+func withMethod(value any, name string) *LoxInstance {
+	instance, ok := value.(*LoxInstance)
+	if !ok || instance.class.findMethod(name) == nil {
+		return nil
+	}
+
+	return instance
+}
+
+// indexable returns what object is indexed through: the object itself, or
+// for an instance whose class defines __index__ or __setindex__, an adapter
+// calling those methods.
+func (i *Interpreter) indexable(bracket lexer.Token, object any) (Indexable, error) {
+	if indexable, ok := object.(Indexable); ok {
+		return indexable, nil
+	}
+	if instance, ok := object.(*LoxInstance); ok {
+		if instance.class.findMethod("__index__") != nil || instance.class.findMethod("__setindex__") != nil {
+			return &instanceIndexer{interpreter: i, instance: instance}, nil
+		}
+	}
+
+	return nil, runtimeError(bracket, "Only lists, maps and instances defining __index__ can be indexed")
+}
+
+type instanceIndexer struct {
+	interpreter *Interpreter
+	instance    *LoxInstance
+}
+
+func (x *instanceIndexer) getIndex(token lexer.Token, index any) (any, error) {
+	if x.instance.class.findMethod("__index__") == nil {
+		return nil, runtimeError(token, fmt.Sprintf("Class %s doesn't define __index__", x.instance.class.name))
+	}
+
+	return x.interpreter.callSpecial(token, x.instance, "__index__", index)
+}
+
+func (x *instanceIndexer) setIndex(token lexer.Token, index any, value any) error {
+	if x.instance.class.findMethod("__setindex__") == nil {
+		return runtimeError(token, fmt.Sprintf("Class %s doesn't define __setindex__", x.instance.class.name))
+	}
+
+	_, err := x.interpreter.callSpecial(token, x.instance, "__setindex__", index, value)
+	return err
+}
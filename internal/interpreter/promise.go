@@ -0,0 +1,330 @@
+package interpreter
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/umed-hotamov/golox/internal/ast"
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+type promiseState int
+
+const (
+	pending promiseState = iota
+	fulfilled
+	rejected
+)
+
+// Promise is the eventual result of an asynchronous operation. Callbacks
+// registered with then and catch, and async functions awaiting it, run as
+// event loop jobs once it settles, never right away.
+type Promise struct {
+	loop *EventLoop
+
+	mu    sync.Mutex
+	state promiseState
+	value any
+	// err is the failure that rejected the promise, if Lox code failed
+	// rather than calling reject, and keeps its position for reporting.
+	err       error
+	handled   bool
+	reactions []job
+}
+
+func NewPromise(loop *EventLoop) *Promise {
+	return &Promise{
+		loop: loop,
+	}
+}
+
+// resolve fulfills the promise with value, or makes it follow value if that
+// is a promise itself.
+func (p *Promise) resolve(value any) {
+	other, ok := value.(*Promise)
+	if !ok {
+		p.settle(fulfilled, value, nil)
+		return
+	}
+
+	other.subscribe(func(interpreter *Interpreter) error {
+		p.settle(other.outcome())
+		return nil
+	})
+}
+
+func (p *Promise) reject(value any) {
+	p.settle(rejected, value, nil)
+}
+
+// fail rejects the promise with the value a catch clause would bind for err.
+// Errors that aren't failures are returned, as they must keep unwinding.
+func (p *Promise) fail(err error) error {
+	value, ok := caughtValue(err)
+	if !ok {
+		return err
+	}
+
+	p.settle(rejected, value, err)
+	return nil
+}
+
+// settle sets the outcome of a pending promise and queues its reactions.
+// Promises settle only once; later calls are ignored.
+func (p *Promise) settle(state promiseState, value any, err error) {
+	p.mu.Lock()
+	if p.state != pending {
+		p.mu.Unlock()
+		return
+	}
+
+	p.state, p.value, p.err = state, value, err
+	reactions, handled := p.reactions, p.handled
+	p.reactions = nil
+	p.mu.Unlock()
+
+	for _, reaction := range reactions {
+		p.loop.enqueue(reaction)
+	}
+	if state == rejected && !handled {
+		p.loop.rejectedUnhandled(p)
+	}
+}
+
+// subscribe queues reaction once the promise settles, or right away if it
+// has. A promise with a reaction counts as handled when it is rejected.
+func (p *Promise) subscribe(reaction job) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handled = true
+	if p.state == pending {
+		p.reactions = append(p.reactions, reaction)
+		return
+	}
+
+	p.loop.enqueue(reaction)
+}
+
+func (p *Promise) outcome() (promiseState, any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.state, p.value, p.err
+}
+
+func (p *Promise) isHandled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.handled
+}
+
+// then returns a promise of what callback returns for the value this promise
+// settles with in state. Settling in the other state passes straight
+// through to the returned promise.
+func (p *Promise) then(state promiseState, callback Callable) *Promise {
+	next := NewPromise(p.loop)
+
+	p.subscribe(func(interpreter *Interpreter) error {
+		settled, value, err := p.outcome()
+		if settled != state {
+			next.settle(settled, value, err)
+			return nil
+		}
+
+		result, err := callWithValue(interpreter, callback, value)
+		if err != nil {
+			return next.fail(err)
+		}
+
+		next.resolve(result)
+		return nil
+	})
+
+	return next
+}
+
+// callWithValue calls callback with value, or with no arguments if it
+// doesn't take any.
+func callWithValue(interpreter *Interpreter, callback Callable, value any) (any, error) {
+	if callback.arity().accepts(1) {
+		return callback.call(interpreter, []any{value})
+	}
+
+	return callback.call(interpreter, nil)
+}
+
+// checkCallback checks that value can be called with one argument or none.
+func checkCallback(value any) (Callable, error) {
+	callback, ok := value.(Callable)
+	if !ok || !callback.arity().accepts(0) && !callback.arity().accepts(1) {
+		return nil, argumentError("Callback must be a function taking one argument or none")
+	}
+
+	return callback, nil
+}
+
+func (p *Promise) get(name lexer.Token) (any, error) {
+	switch name.Lexeme {
+	case "then", "catch":
+		state := fulfilled
+		if name.Lexeme == "catch" {
+			state = rejected
+		}
+
+		return NewNativeFunction(name.Lexeme, 1, func(interpreter *Interpreter, arguments []any) (any, error) {
+			callback, err := checkCallback(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+
+			return p.then(state, callback), nil
+		}), nil
+	}
+
+	return nil, runtimeError(name, fmt.Sprintf("Undefined property '%s'", name.Lexeme))
+}
+
+func (p *Promise) String() string {
+	state, _, _ := p.outcome()
+	switch state {
+	case fulfilled:
+		return "<promise fulfilled>"
+	case rejected:
+		return "<promise rejected>"
+	}
+
+	return "<promise pending>"
+}
+
+// PromiseConstructor is the built-in 'Promise'. Promise(executor) calls
+// executor right away with a resolve and a reject function, either of which
+// settles the promise, and rejects the promise if executor fails.
+type PromiseConstructor struct {
+}
+
+func (c PromiseConstructor) arity() arityRange {
+	return exactly(1)
+}
+
+func (c PromiseConstructor) call(interpreter *Interpreter, arguments []any) (any, error) {
+	executor, ok := arguments[0].(Callable)
+	if !ok || !executor.arity().accepts(2) {
+		return nil, argumentError("Promise executor must be a function taking two arguments")
+	}
+
+	promise := NewPromise(interpreter.loop)
+	resolve := &settler{promise: promise, name: "resolve"}
+	reject := &settler{promise: promise, name: "reject", reject: true}
+	if _, err := executor.call(interpreter, []any{resolve, reject}); err != nil {
+		if err := promise.fail(err); err != nil {
+			return nil, err
+		}
+	}
+
+	return promise, nil
+}
+
+func (c PromiseConstructor) String() string {
+	return "<native fn Promise>"
+}
+
+// settler is the resolve or reject function handed to a promise executor.
+// The value is optional, so settlers can be used as timer callbacks.
+type settler struct {
+	promise *Promise
+	name    string
+	reject  bool
+}
+
+func (s *settler) arity() arityRange {
+	return arityRange{min: 0, max: 1}
+}
+
+func (s *settler) call(interpreter *Interpreter, arguments []any) (any, error) {
+	var value any
+	if len(arguments) > 0 {
+		value = arguments[0]
+	}
+
+	if s.reject {
+		s.promise.reject(value)
+	} else {
+		s.promise.resolve(value)
+	}
+
+	return nil, nil
+}
+
+func (s *settler) String() string {
+	return fmt.Sprintf("<native fn %s>", s.name)
+}
+
+// asyncCall runs the body of a call to an async function. Like a generator's,
+// the body runs on a goroutine of its own taking turns with the code that
+// started or resumed it: it runs until it awaits, and is resumed by an event
+// loop job once the awaited promise settles.
+type asyncCall struct {
+	interpreter *Interpreter
+	promise     *Promise
+
+	resume    chan struct{}
+	suspended chan struct{}
+}
+
+// startAsync starts running body in env and returns the promise of its
+// result once the body first awaits or finishes.
+func startAsync(interpreter *Interpreter, body ast.Block, env *Environment) *Promise {
+	call := &asyncCall{
+		promise:   NewPromise(interpreter.loop),
+		resume:    make(chan struct{}),
+		suspended: make(chan struct{}),
+	}
+
+	call.interpreter = interpreter.fork(env)
+	call.interpreter.async = call
+
+	go call.run(body)
+	<-call.suspended
+
+	return call.promise
+}
+
+func (a *asyncCall) run(body ast.Block) {
+	err := a.interpreter.executeBlock(body, a.interpreter.env)
+
+	if signal, returned := err.(*returnSignal); returned {
+		a.promise.resolve(signal.value)
+	} else if err != nil {
+		// Only failures can get out of a function body.
+		a.promise.fail(err)
+	} else {
+		a.promise.resolve(nil)
+	}
+
+	a.suspended <- struct{}{}
+}
+
+// await suspends the body until promise settles and returns its value, or
+// the failure that rejected it. It is called on the body's own goroutine.
+func (a *asyncCall) await(keyword lexer.Token, promise *Promise) (any, error) {
+	promise.subscribe(func(interpreter *Interpreter) error {
+		a.resume <- struct{}{}
+		<-a.suspended
+		return nil
+	})
+
+	a.suspended <- struct{}{}
+	<-a.resume
+
+	state, value, err := promise.outcome()
+	if state == fulfilled {
+		return value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, &Throw{keyword: keyword, value: value}
+}
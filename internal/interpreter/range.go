@@ -0,0 +1,124 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/umed-hotamov/golox/internal/lexer"
+)
+
+// Container is implemented by runtime values that support 'in' membership
+// tests.
+type Container interface {
+	contains(interpreter *Interpreter, token lexer.Token, value any) (bool, error)
+}
+
+// contains reports whether item is in container: an element of a list, a key
+// of a map, a substring of a string or a number in a range.
+func contains(interpreter *Interpreter, operator lexer.Token, item any, container any) (bool, error) {
+	switch container := container.(type) {
+	case Container:
+		return container.contains(interpreter, operator, item)
+	case string:
+		if !isString(item) {
+			return false, runtimeError(operator, "Only strings can be searched for in a string")
+		}
+		return strings.Contains(container, item.(string)), nil
+	}
+
+	return false, runtimeError(operator, "Can only test membership in lists, maps, strings and ranges")
+}
+
+// LoxRange is the sequence of integers from start towards end, advancing by
+// step. Its values are computed as they are needed, so large ranges cost no
+// more than small ones.
+type LoxRange struct {
+	start     int64
+	end       int64
+	step      int64
+	inclusive bool
+}
+
+func NewLoxRange(start int64, end int64, step int64, inclusive bool) *LoxRange {
+	return &LoxRange{
+		start:     start,
+		end:       end,
+		step:      step,
+		inclusive: inclusive,
+	}
+}
+
+// inBounds reports whether n lies between start and end, in the direction
+// of step.
+func (r *LoxRange) inBounds(n int64) bool {
+	if r.step > 0 {
+		return n >= r.start && (n < r.end || r.inclusive && n == r.end)
+	}
+
+	return n <= r.start && (n > r.end || r.inclusive && n == r.end)
+}
+
+func (r *LoxRange) contains(interpreter *Interpreter, token lexer.Token, value any) (bool, error) {
+	n, ok := toInteger(value)
+	if !ok || !r.inBounds(n) {
+		return false, nil
+	}
+
+	// Unsigned arithmetic keeps the distance exact across the whole int64
+	// range.
+	distance, step := uint64(n)-uint64(r.start), uint64(r.step)
+	if r.step < 0 {
+		distance, step = uint64(r.start)-uint64(n), -step
+	}
+
+	return distance%step == 0, nil
+}
+
+func (r *LoxRange) iterator(interpreter *Interpreter, token lexer.Token) Iterator {
+	return r.walk()
+}
+
+func (r *LoxRange) walk() *rangeIterator {
+	return &rangeIterator{r: r, current: r.start, done: !r.inBounds(r.start)}
+}
+
+func (r *LoxRange) String() string {
+	operator := ".."
+	if !r.inclusive {
+		operator = "..<"
+	}
+	if r.step != 1 {
+		return fmt.Sprintf("%d%s%d step %d", r.start, operator, r.end, r.step)
+	}
+
+	return fmt.Sprintf("%d%s%d", r.start, operator, r.end)
+}
+
+type rangeIterator struct {
+	r       *LoxRange
+	current int64
+	done    bool
+}
+
+func (it *rangeIterator) hasNext() (bool, error) {
+	return !it.done, nil
+}
+
+func (it *rangeIterator) next() (any, error) {
+	return it.advance(), nil
+}
+
+func (it *rangeIterator) advance() int64 {
+	value := it.current
+
+	step := it.r.step
+	if step > 0 && value > math.MaxInt64-step || step < 0 && value < math.MinInt64-step {
+		it.done = true
+	} else {
+		it.current += step
+		it.done = !it.r.inBounds(it.current)
+	}
+
+	return value
+}
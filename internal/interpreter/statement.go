@@ -1,101 +1,466 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/umed-hotamov/golox/internal/ast"
 )
 
-func (i *Interpreter) execute(statement ast.Stmt) {
+// Statements that don't complete normally report why through the error they
+// return: a *RuntimeError or *Throw for failures, and the signals below for
+// return, break and continue. Each signal is consumed by the construct it
+// targets, so only failures ever reach Interpret.
+var (
+	errBreak    = errors.New("break outside of a loop")
+	errContinue = errors.New("continue outside of a loop")
+)
+
+// returnSignal carries a returned value up to Function.call.
+type returnSignal struct {
+	value any
+}
+
+func (r *returnSignal) Error() string {
+	return "return outside of a function"
+}
+
+func (i *Interpreter) execute(statement ast.Stmt) error {
 	switch statement.(type) {
 	case ast.Expression:
-		i.executeExpression(statement.(ast.Expression))
+		return i.executeExpression(statement.(ast.Expression))
 	case ast.Print:
-		i.executePrint(statement.(ast.Print))
+		return i.executePrint(statement.(ast.Print))
 	case ast.Var:
-		i.executeVar(statement.(ast.Var))
+		return i.executeVar(statement.(ast.Var))
 	case ast.Block:
-		i.executeBlock(statement.(ast.Block), NewEnclosingEnvironment(i.env))
+		return i.executeBlock(statement.(ast.Block), NewEnclosingEnvironment(i.env))
 	case ast.If:
-		i.executeIf(statement.(ast.If))
+		return i.executeIf(statement.(ast.If))
 	case ast.While:
-		i.executeWhile(statement.(ast.While))
+		return i.executeWhile(statement.(ast.While))
+	case ast.Break:
+		return errBreak
+	case ast.Continue:
+		return errContinue
 	case ast.Function:
-		i.executeFunction(statement.(ast.Function))
+		return i.executeFunction(statement.(ast.Function))
 	case ast.Return:
-		i.executeReturn(statement.(ast.Return))
+		return i.executeReturn(statement.(ast.Return))
 	case ast.Class:
-		i.executeClass(statement.(ast.Class))
+		return i.executeClass(statement.(ast.Class))
+	case ast.Throw:
+		return i.executeThrow(statement.(ast.Throw))
+	case ast.Yield:
+		return i.executeYield(statement.(ast.Yield))
+	case ast.Try:
+		return i.executeTry(statement.(ast.Try))
+	case ast.Match:
+		return i.executeMatch(statement.(ast.Match))
+	case ast.ForIn:
+		return i.executeForIn(statement.(ast.ForIn))
+	case ast.Spawn:
+		return i.executeSpawn(statement.(ast.Spawn))
+	case ast.Select:
+		return i.executeSelect(statement.(ast.Select))
+	case ast.Import:
+		return i.executeImport(statement.(ast.Import))
 	}
+
+	return nil
 }
 
-func (i *Interpreter) executeExpression(statement ast.Expression) {
-	i.evaluate(statement.Expression)
+func (i *Interpreter) executeExpression(statement ast.Expression) error {
+	_, err := i.evaluate(statement.Expression)
+	return err
 }
 
-func (i *Interpreter) executePrint(statement ast.Print) {
-	value := i.evaluate(statement.Expression)
+func (i *Interpreter) executePrint(statement ast.Print) error {
+	value, err := i.evaluate(statement.Expression)
+	if err != nil {
+		return err
+	}
 
-	if value == nil {
-		fmt.Println("nil")
-		return
+	text, err := i.str(value)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println(value)
+	fmt.Println(text)
+	return nil
 }
 
-func (i *Interpreter) executeVar(statement ast.Var) {
+func (i *Interpreter) executeVar(statement ast.Var) error {
 	var value any = nil
 	if statement.Initializer != nil {
-		value = i.evaluate(statement.Initializer)
+		var err error
+		if value, err = i.evaluate(statement.Initializer); err != nil {
+			return err
+		}
 	}
 
-	i.env.define(statement.Name.Lexeme, value)
+	if statement.Const {
+		return i.env.defineConstant(statement.Name, value)
+	}
+	return i.env.define(statement.Name, value)
 }
 
-func (i *Interpreter) executeBlock(statement ast.Block, env *Environment) {
+func (i *Interpreter) executeBlock(statement ast.Block, env *Environment) error {
 	previous := i.env
 	i.env = env
 
-	defer func() {
-		i.env = previous
-	}()
-
+	var err error
 	for _, stmt := range statement.Statements {
-		i.execute(stmt)
+		if err = i.execute(stmt); err != nil {
+			break
+		}
 	}
+
+	i.env = previous
+	return err
 }
 
-func (i *Interpreter) executeIf(statement ast.If) {
-	if isTruthy(i.evaluate(statement.Condition)) {
-		i.execute(statement.ThenBranch)
+func (i *Interpreter) executeIf(statement ast.If) error {
+	condition, err := i.evaluate(statement.Condition)
+	if err != nil {
+		return err
+	}
+
+	if isTruthy(condition) {
+		return i.execute(statement.ThenBranch)
 	} else if statement.ElseBranch != nil {
-		i.execute(statement.ElseBranch)
+		return i.execute(statement.ElseBranch)
 	}
+
+	return nil
 }
 
-func (i *Interpreter) executeWhile(statement ast.While) {
-	for isTruthy(i.evaluate(statement.Condition)) {
-		i.execute(statement.Body)
+func (i *Interpreter) executeWhile(statement ast.While) error {
+	for {
+		condition, err := i.evaluate(statement.Condition)
+		if err != nil {
+			return err
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		err = i.execute(statement.Body)
+		if err == errBreak {
+			return nil
+		}
+		if err != nil && err != errContinue {
+			return err
+		}
+
+		if statement.Increment != nil {
+			if _, err := i.evaluate(statement.Increment); err != nil {
+				return err
+			}
+		}
 	}
 }
 
-func (i *Interpreter) executeFunction(statement ast.Function) {
-	function := NewFunction(statement, i.env)
-	i.env.define(statement.Name.Lexeme, function)
+func (i *Interpreter) executeFunction(statement ast.Function) error {
+	function := NewFunction(statement, i.env, false)
+	return i.env.define(statement.Name, function)
 }
 
-func (i *Interpreter) executeReturn(statement ast.Return) {
+func (i *Interpreter) executeReturn(statement ast.Return) error {
 	var value any
 	if statement.Value != nil {
-		value = i.evaluate(statement.Value)
+		var err error
+		if value, err = i.evaluate(statement.Value); err != nil {
+			return err
+		}
 	}
 
-	panic(value)
+	return &returnSignal{value: value}
 }
 
-func (i *Interpreter) executeClass(statement ast.Class) {
-	i.env.define(statement.Name.Lexeme, nil)
-	class := NewLoxClass(statement.Name.Lexeme)
-	i.env.assign(statement.Name, class)
+func (i *Interpreter) executeClass(statement ast.Class) error {
+	var superclass *LoxClass
+	if statement.Superclass != nil {
+		value, err := i.evaluate(statement.Superclass)
+		if err != nil {
+			return err
+		}
+
+		class, ok := value.(*LoxClass)
+		if !ok {
+			return runtimeError(statement.Superclass.(ast.Variable).Name, "Superclass must be a class")
+		}
+
+		superclass = class
+	}
+
+	if err := i.env.define(statement.Name, nil); err != nil {
+		return err
+	}
+
+	env := i.env
+	if superclass != nil {
+		env = NewEnclosingEnvironment(i.env)
+		env.bind("super", superclass)
+	}
+
+	methods := make(map[string]*Function)
+	for _, method := range statement.Methods {
+		methods[method.Name.Lexeme] = NewFunction(method, env, method.Name.Lexeme == "init")
+	}
+
+	class := NewLoxClass(statement.Name.Lexeme, superclass, methods)
+	return i.env.assign(statement.Name, class)
+}
+
+func (i *Interpreter) executeThrow(statement ast.Throw) error {
+	value, err := i.evaluate(statement.Value)
+	if err != nil {
+		return err
+	}
+
+	return &Throw{keyword: statement.Keyword, value: value}
+}
+
+func (i *Interpreter) executeTry(statement ast.Try) error {
+	err := i.execute(statement.Body)
+
+	if statement.Catch != nil {
+		if thrown, caught := caughtValue(err); caught {
+			env := NewEnclosingEnvironment(i.env)
+			env.bind(statement.Name.Lexeme, thrown)
+			err = i.executeBlock(statement.Catch.(ast.Block), env)
+		}
+	}
+
+	if statement.Finally != nil {
+		// An abrupt finally block overrides however the try statement ended.
+		if finallyErr := i.execute(statement.Finally); finallyErr != nil {
+			return finallyErr
+		}
+	}
+
+	return err
+}
+
+// caughtValue returns the value a catch clause binds for err. Return, break
+// and continue are not exceptions and can't be caught.
+func caughtValue(err error) (any, bool) {
+	switch err := err.(type) {
+	case *Throw:
+		return err.value, true
+	case *RuntimeError:
+		return err, true
+	}
+
+	return nil, false
+}
+
+func (i *Interpreter) executeForIn(statement ast.ForIn) (err error) {
+	iterable, err := i.evaluate(statement.Iterable)
+	if err != nil {
+		return err
+	}
+	iterator, err := i.iterate(statement.In, iterable)
+	if err != nil {
+		return err
+	}
+
+	// Iterators are closed however the loop ends; it's a no-op for ones
+	// that ran to the end.
+	if closer, ok := iterator.(closer); ok {
+		defer func() {
+			if closeErr := closer.close(); err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	for {
+		more, err := iterator.hasNext()
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		value, err := iterator.next()
+		if err != nil {
+			return err
+		}
+
+		env := NewEnclosingEnvironment(i.env)
+		env.bind(statement.Name.Lexeme, value)
+
+		err = i.executeBlock(ast.Block{Statements: []ast.Stmt{statement.Body}}, env)
+		if err == errBreak {
+			return nil
+		}
+		if err != nil && err != errContinue {
+			return err
+		}
+	}
+}
+
+func (i *Interpreter) executeYield(statement ast.Yield) error {
+	var value any
+	if statement.Value != nil {
+		var err error
+		if value, err = i.evaluate(statement.Value); err != nil {
+			return err
+		}
+	}
+
+	return i.generator.yield(value)
+}
+
+func (i *Interpreter) executeMatch(statement ast.Match) error {
+	value, err := i.evaluate(statement.Value)
+	if err != nil {
+		return err
+	}
+
+	for _, arm := range statement.Arms {
+		env := NewEnclosingEnvironment(i.env)
+		if arm.Patterns != nil {
+			matched, err := i.matchAny(arm.Patterns, value, env)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		if arm.Guard != nil {
+			guard, err := i.evaluateIn(arm.Guard, env)
+			if err != nil {
+				return err
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return i.executeBlock(ast.Block{Statements: []ast.Stmt{arm.Body}}, env)
+	}
+
+	return nil
+}
+
+func (i *Interpreter) matchAny(patterns []ast.Pattern, value any, env *Environment) (bool, error) {
+	for _, pattern := range patterns {
+		if matched, err := i.matchPattern(pattern, value, env); matched || err != nil {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+// matchPattern reports whether value matches pattern, defining the names it
+// binds in env along the way. Literals are compared like with ==, so an
+// instance matches them through its __eq__ method.
+func (i *Interpreter) matchPattern(pattern ast.Pattern, value any, env *Environment) (bool, error) {
+	switch pattern := pattern.(type) {
+	case ast.LiteralPattern:
+		return i.equal(pattern.Token, value, pattern.Value)
+	case ast.WildcardPattern:
+		return true, nil
+	case ast.BindingPattern:
+		env.bind(pattern.Name.Lexeme, value)
+		return true, nil
+	case ast.ListPattern:
+		list, ok := value.(*LoxList)
+		if !ok {
+			return false, nil
+		}
+
+		elements := list.snapshot()
+		if len(elements) != len(pattern.Elements) {
+			return false, nil
+		}
+
+		for j, element := range pattern.Elements {
+			if matched, err := i.matchPattern(element, elements[j], env); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (i *Interpreter) executeSpawn(statement ast.Spawn) error {
+	function, arguments, err := i.prepareCall(statement.Call)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is left to catch an error once the goroutine runs, so it is
+	// reported there and doesn't stop the rest of the program.
+	i.loop.taskStarted()
+	i.goroutines.start()
+	go func(interpreter *Interpreter) {
+		defer interpreter.loop.taskDone()
+		defer interpreter.goroutines.stop()
+
+		if _, err := interpreter.call(statement.Call.Paren, function, arguments); err != nil {
+			fmt.Println(err)
+		}
+	}(i.fork(i.env))
+
+	return nil
+}
+
+// executeSelect evaluates the channels and sent values of all arms before
+// waiting on them. A receive from a closed channel is always ready and
+// yields nil.
+func (i *Interpreter) executeSelect(statement ast.Select) error {
+	var ops []channelOp
+	var arms []ast.SelectArm
+	var fallback *ast.SelectArm
+	for j, arm := range statement.Arms {
+		if arm.Channel == nil {
+			fallback = &statement.Arms[j]
+			continue
+		}
+
+		value, err := i.evaluate(arm.Channel)
+		if err != nil {
+			return err
+		}
+		channel, ok := value.(*Channel)
+		if !ok {
+			return runtimeError(arm.Operation, "Can only select on channels")
+		}
+
+		op := channelOp{channel: channel}
+		if arm.Value != nil {
+			if op.value, err = i.evaluate(arm.Value); err != nil {
+				return err
+			}
+			op.send = true
+		}
+		ops = append(ops, op)
+		arms = append(arms, arm)
+	}
+
+	chosen, received, _, err := i.goroutines.perform(statement.Keyword, ops, fallback == nil)
+	if err != nil {
+		return err
+	}
+
+	env := NewEnclosingEnvironment(i.env)
+	if chosen < 0 {
+		return i.executeBlock(ast.Block{Statements: []ast.Stmt{fallback.Body}}, env)
+	}
+
+	arm := arms[chosen]
+	if arm.Name != nil {
+		env.bind(arm.Name.Lexeme, received)
+	}
+
+	return i.executeBlock(ast.Block{Statements: []ast.Stmt{arm.Body}}, env)
 }
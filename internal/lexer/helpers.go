@@ -2,21 +2,28 @@ package lexer
 
 import (
   "strconv"
-	"strings"
+  "strings"
+  "unicode"
+  "unicode/utf8"
 )
 
-func (l *Lexer) isAlpha(c byte) bool {
-  return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' 
+func (l *Lexer) isAlpha(c rune) bool {
+  return unicode.IsLetter(c) || c == '_'
 }
 
-func (l *Lexer) isDigit(c byte) bool {
+func (l *Lexer) isDigit(c rune) bool {
   return c >= '0' && c <= '9'
 }
 
-func (l *Lexer) isAlphaNumeric(c byte) bool {
+func (l *Lexer) isHexDigit(c rune) bool {
+  return l.isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func (l *Lexer) isAlphaNumeric(c rune) bool {
   return l.isAlpha(c) || l.isDigit(c)
 }
 
+// nextLine is called right after a newline has been consumed.
 func (l *Lexer) nextLine() {
   l.startColumn = l.current
   l.line += 1
@@ -31,11 +38,11 @@ func (l *Lexer) acceptBlockComments() {
     } else if l.peek() == '/' && l.peekNext() == '*' {
       depth += 1
       l.advance()
-    } else if l.peek() == '\n' {
-      l.nextLine()
     }
 
-    l.advance()
+    if l.advance() == '\n' {
+      l.nextLine()
+    }
   }
 
   if depth > 0 {
@@ -48,8 +55,8 @@ func (l *Lexer) acceptIdentifier() {
     l.advance()
   }
 
-  literal := l.source[l.start:l.current]
-  
+  literal := string(l.source[l.start:l.current])
+
   tokenType, ok := keywords[literal]
   if !ok {
     tokenType = IDENTIFIER
@@ -58,29 +65,220 @@ func (l *Lexer) acceptIdentifier() {
   l.addTokenLiteral(tokenType, literal)
 }
 
+// acceptNumber scans a decimal literal with optional fraction and exponent,
+// or an integer with a 0x, 0o or 0b prefix. Digits may be grouped with
+// single underscores, as in 1_000_000. Literals without a fraction or an
+// exponent are integers (int64), the others floats (float64).
 func (l *Lexer) acceptNumber() {
-  digits := "0123456789"
-  l.acceptRun(digits)
-  if l.accept('.') && l.isDigit(l.peek()) {
-    l.acceptRun(digits)
+  l.current = l.start
+
+  if l.peek() == '0' && strings.ContainsRune("xXoObB", l.peekNext()) {
+    l.acceptRadixNumber()
+    return
+  }
+
+  valid := l.acceptDigits(decimalDigits)
+  isFloat := false
+  if l.peek() == '.' && l.isDigit(l.peekNext()) {
+    l.advance()
+    isFloat = true
+    valid = l.acceptDigits(decimalDigits) && valid
+  }
+  if l.peek() == 'e' || l.peek() == 'E' {
+    isFloat = true
+    l.advance()
+    if !l.accept('+') {
+      l.accept('-')
+    }
+    valid = l.acceptDigits(decimalDigits) && valid
+  }
+
+  if !l.endNumber(valid) {
+    return
+  }
+
+  text := strings.ReplaceAll(string(l.source[l.start:l.current]), "_", "")
+  if !isFloat {
+    l.addIntegerLiteral(text, 10)
+    return
+  }
+
+  number, err := strconv.ParseFloat(text, 64)
+  if err != nil {
+    l.error("Number literal out of range")
+    return
+  }
+
+  l.addTokenLiteral(NUMBER, number)
+}
+
+func (l *Lexer) addIntegerLiteral(text string, base int) {
+  number, err := strconv.ParseInt(text, base, 64)
+  if err != nil {
+    l.error("Integer literal out of range")
+    return
   }
 
-  number, _ := strconv.ParseFloat(l.source[l.start:l.current], 64)
   l.addTokenLiteral(NUMBER, number)
 }
 
+const (
+  binaryDigits  = "01"
+  octalDigits   = "01234567"
+  decimalDigits = "0123456789"
+  hexDigits     = "0123456789abcdefABCDEF"
+)
+
+func (l *Lexer) acceptRadixNumber() {
+  l.advance()
+  prefix := l.advance()
+
+  base, digits := 16, hexDigits
+  switch prefix {
+  case 'o', 'O':
+    base, digits = 8, octalDigits
+  case 'b', 'B':
+    base, digits = 2, binaryDigits
+  }
+
+  start := l.current
+  if !l.endNumber(l.acceptDigits(digits)) {
+    return
+  }
+
+  l.addIntegerLiteral(strings.ReplaceAll(string(l.source[start:l.current]), "_", ""), base)
+}
+
+// acceptDigits consumes a run of digits separated by single underscores and
+// reports whether it was well formed: not empty, and with no leading,
+// trailing or doubled underscores.
+func (l *Lexer) acceptDigits(digits string) bool {
+  start := l.current
+  l.acceptRun(digits + "_")
+
+  run := string(l.source[start:l.current])
+  return run != "" && run[0] != '_' && run[len(run)-1] != '_' && !strings.Contains(run, "__")
+}
+
+// endNumber reports a malformed literal, including one running straight into
+// letters or other digits as in 0b102 or 12abc.
+func (l *Lexer) endNumber(valid bool) bool {
+  if l.isAlphaNumeric(l.peek()) {
+    for l.isAlphaNumeric(l.peek()) {
+      l.advance()
+    }
+    valid = false
+  }
+
+  if !valid {
+    l.error("Malformed number literal")
+  }
+
+  return valid
+}
+
+// acceptString scans a string literal up to its closing quote. A string with
+// embedded expressions is split at each "${": the text before it becomes an
+// INTERPOLATION token, the expression is lexed as ordinary tokens, and
+// scanning resumes here at the matching '}'. The last segment is a STRING.
 func (l *Lexer) acceptString() {
-  l.skipTo('"')
+  var value strings.Builder
+  for !l.eof() && l.peek() != '"' {
+    if l.peek() == '$' && l.peekNext() == '{' {
+      l.advance()
+      l.advance()
+      l.interpolations = append(l.interpolations, 0)
+      l.addTokenLiteral(INTERPOLATION, value.String())
+      return
+    }
+
+    c := l.advance()
+    switch c {
+    case '\n':
+      l.nextLine()
+    case '\\':
+      l.acceptEscape(&value)
+      continue
+    }
+
+    value.WriteRune(c)
+  }
+
   if l.eof() {
     l.error("Unterminated string")
     return
   }
 
   l.advance()
-  l.addTokenLiteral(STRING, l.source[l.start + 1:l.current-1])
+  l.addTokenLiteral(STRING, value.String())
+}
+
+// acceptRawString scans a backquoted string, which may span lines and has no
+// escape sequences.
+func (l *Lexer) acceptRawString() {
+  l.skipTo('`')
+  if l.eof() {
+    l.error("Unterminated raw string")
+    return
+  }
+
+  l.advance()
+  l.addTokenLiteral(STRING, string(l.source[l.start+1:l.current-1]))
+}
+
+var escapes = map[rune]rune{
+  'n':  '\n',
+  't':  '\t',
+  'r':  '\r',
+  '0':  0,
+  '"':  '"',
+  '$':  '$',
+  '\\': '\\',
+}
+
+// acceptEscape decodes the escape sequence following a backslash.
+func (l *Lexer) acceptEscape(value *strings.Builder) {
+  if l.eof() {
+    return
+  }
+
+  c := l.advance()
+  if escaped, ok := escapes[c]; ok {
+    value.WriteRune(escaped)
+    return
+  }
+
+  if c != 'u' {
+    l.error("Invalid escape sequence '\\" + string(c) + "'")
+    return
+  }
+
+  if !l.accept('{') {
+    l.error("Expect '{' after '\\u'")
+    return
+  }
+
+  start := l.current
+  for !l.eof() && l.isHexDigit(l.peek()) {
+    l.advance()
+  }
+  digits := string(l.source[start:l.current])
+
+  if !l.accept('}') {
+    l.error("Expect '}' after unicode escape")
+    return
+  }
+
+  code, err := strconv.ParseUint(digits, 16, 32)
+  if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+    l.error("Invalid unicode escape")
+    return
+  }
+
+  value.WriteRune(rune(code))
 }
 
-func (l *Lexer) accept(valid byte) bool {
+func (l *Lexer) accept(valid rune) bool {
   if l.peek() == valid {
     l.advance()
     return true
@@ -90,27 +288,26 @@ func (l *Lexer) accept(valid byte) bool {
 }
 
 func (l *Lexer) acceptRun(valid string) {
-  for !l.eof() && strings.ContainsRune(valid, rune(l.peek())) {
+  for !l.eof() && strings.ContainsRune(valid, l.peek()) {
     l.advance()
   }
 }
 
-func (l *Lexer) skipTo(to byte) {
+func (l *Lexer) skipTo(to rune) {
   for !l.eof() && l.peek() != to {
-    if l.peek() == '\n' {
+    if l.advance() == '\n' {
       l.nextLine()
     }
-    l.advance()
   }
 }
 
-func (l *Lexer) advance() byte {
+func (l *Lexer) advance() rune {
   current := l.current
   l.current += 1
   return l.source[current]
 }
 
-func (l *Lexer) peek() byte {
+func (l *Lexer) peek() rune {
   if l.eof() {
     return 0 
   } 
@@ -118,7 +315,7 @@ func (l *Lexer) peek() byte {
   return l.source[l.current]
 }
 
-func (l *Lexer) peekNext() byte {
+func (l *Lexer) peekNext() rune {
   if l.current + 1 >= len(l.source) {
     return 0
   }
@@ -135,7 +332,7 @@ func (l *Lexer) addToken(tokenType TokenType) {
 }
 
 func (l *Lexer) addTokenLiteral(tokenType TokenType, literal any) {
-  lexeme := l.source[l.start:l.current]
+  lexeme := string(l.source[l.start:l.current])
   column := l.current - l.startColumn
   l.tokens = append(l.tokens, NewToken(tokenType, lexeme, literal, l.line, column))
 }
@@ -4,8 +4,10 @@ import (
 	"fmt"
 )
 
+// Lexer scans source text rune by rune, so positions and columns count
+// characters rather than bytes.
 type Lexer struct {
-  source      string
+  source      []rune
   tokens      []*Token
 
   line        int
@@ -15,13 +17,17 @@ type Lexer struct {
   startColumn int
   current     int
 
+  // interpolations holds, for each string interpolation being scanned, how
+  // many braces are open inside its embedded expression.
+  interpolations []int
+
   HasError    bool
 }
 
 func NewLexer(source string) *Lexer {
   tokens := make([]*Token, 0)
   return &Lexer{
-    source: source,
+    source: []rune(source),
     tokens: tokens,
     line: 1,
   }
@@ -29,22 +35,45 @@ func NewLexer(source string) *Lexer {
 
 
 var keywords = map[string]TokenType{
-  "and":    AND,
-  "or":     OR,
-  "class":  CLASS,
-  "else":   ELSE,
-  "false":  FALSE,
-  "true":   TRUE,
-  "if":     IF,
-  "nil":    NIL,
-  "for":    FOR,
-  "fun":    FUN,
-  "print":  PRINT,
-  "return": RETURN,
-  "super":  SUPER,
-  "this":   THIS,
-  "var":    VAR,
-  "while":  WHILE,
+  "and":      AND,
+  "or":       OR,
+  "async":    ASYNC,
+  "await":    AWAIT,
+  "break":    BREAK,
+  "case":     CASE,
+  "catch":    CATCH,
+  "class":    CLASS,
+  "const":    CONST,
+  "continue": CONTINUE,
+  "default":  DEFAULT,
+  "else":     ELSE,
+  "false":    FALSE,
+  "finally":  FINALLY,
+  "true":     TRUE,
+  "if":       IF,
+  "import":   IMPORT,
+  "in":       IN,
+  "match":    MATCH,
+  "nil":      NIL,
+  "for":      FOR,
+  "fun":      FUN,
+  "print":    PRINT,
+  "return":   RETURN,
+  "select":   SELECT,
+  "spawn":    SPAWN,
+  "super":    SUPER,
+  "this":     THIS,
+  "throw":    THROW,
+  "try":      TRY,
+  "var":      VAR,
+  "while":    WHILE,
+  "yield":    YIELD,
+}
+
+// IsKeyword reports whether word is reserved.
+func IsKeyword(word string) bool {
+  _, ok := keywords[word]
+  return ok
 }
 
 func (l *Lexer) Lex() []*Token {
@@ -54,7 +83,11 @@ func (l *Lexer) Lex() []*Token {
     l.lineStart = l.line
     l.fetchToken()
   }
-  
+
+  if len(l.interpolations) > 0 {
+    l.error("Unterminated string interpolation")
+  }
+
   l.tokens = append(l.tokens, NewToken(EOF, "", nil, l.line, 0))
 
   return l.tokens
@@ -64,23 +97,95 @@ func (l *Lexer) fetchToken() {
   c := l.advance()
   switch c {
     case '{':
+      if len(l.interpolations) > 0 {
+        l.interpolations[len(l.interpolations)-1] += 1
+      }
       l.addToken(LEFT_BRACE)
     case '}':
+      if n := len(l.interpolations); n > 0 {
+        if l.interpolations[n-1] == 0 {
+          // The embedded expression is over, the string continues.
+          l.interpolations = l.interpolations[:n-1]
+          l.acceptString()
+          return
+        }
+        l.interpolations[n-1] -= 1
+      }
       l.addToken(RIGHT_BRACE)
     case '(':
       l.addToken(LEFT_PAREN)
     case ')':
       l.addToken(RIGHT_PAREN)
+    case '[':
+      l.addToken(LEFT_BRACKET)
+    case ']':
+      l.addToken(RIGHT_BRACKET)
     case ',':
       l.addToken(COMMA)
+    case ':':
+      l.addToken(COLON)
     case '.':
-      l.addToken(DOT)
+      if l.accept('.') {
+        if l.accept('.') {
+          l.addToken(DOT_DOT_DOT)
+        } else if l.accept('<') {
+          l.addToken(DOT_DOT_LESS)
+        } else {
+          l.addToken(DOT_DOT)
+        }
+      } else {
+        l.addToken(DOT)
+      }
+    case '?':
+      if l.accept('?') {
+        l.addToken(QUESTION_QUESTION)
+      } else if l.accept('.') {
+        l.addToken(QUESTION_DOT)
+      } else {
+        l.addToken(QUESTION)
+      }
     case '+':
-      l.addToken(PLUS)
+      if l.accept('+') {
+        l.addToken(PLUS_PLUS)
+      } else if l.accept('=') {
+        l.addToken(PLUS_EQUAL)
+      } else {
+        l.addToken(PLUS)
+      }
     case '-':
-      l.addToken(MINUS)
+      if l.accept('-') {
+        l.addToken(MINUS_MINUS)
+      } else if l.accept('=') {
+        l.addToken(MINUS_EQUAL)
+      } else {
+        l.addToken(MINUS)
+      }
     case '*':
-      l.addToken(STAR)
+      if l.accept('*') {
+        l.addToken(STAR_STAR)
+      } else if l.accept('=') {
+        l.addToken(STAR_EQUAL)
+      } else {
+        l.addToken(STAR)
+      }
+    case '%':
+      if l.accept('=') {
+        l.addToken(PERCENT_EQUAL)
+      } else {
+        l.addToken(PERCENT)
+      }
+    case '&':
+      l.addToken(AMPERSAND)
+    case '|':
+      l.addToken(PIPE)
+    case '^':
+      l.addToken(CARET)
+    case '~':
+      if l.accept('/') {
+        l.addToken(TILDE_SLASH)
+      } else {
+        l.addToken(TILDE)
+      }
     case ';':
       l.addToken(SEMICOLON)
     case '!':
@@ -92,18 +197,24 @@ func (l *Lexer) fetchToken() {
     case '=':
       if l.accept('=') {
         l.addToken(EQUAL_EQUAL)
+      } else if l.accept('>') {
+        l.addToken(ARROW)
       } else {
         l.addToken(EQUAL)
       }
     case '>':
       if l.accept('=') {
         l.addToken(GREATER_EQUAL)
+      } else if l.accept('>') {
+        l.addToken(GREATER_GREATER)
       } else {
         l.addToken(GREATER)
       }
     case '<':
       if l.accept('=') {
         l.addToken(LESS_EQUAL)
+      } else if l.accept('<') {
+        l.addToken(LESS_LESS)
       } else {
         l.addToken(LESS)
       }
@@ -112,11 +223,15 @@ func (l *Lexer) fetchToken() {
         l.skipTo('\n')
       } else if l.accept('*') {
         l.acceptBlockComments()
+      } else if l.accept('=') {
+        l.addToken(SLASH_EQUAL)
       } else {
         l.addToken(SLASH)
       }
     case '"':
       l.acceptString()
+    case '`':
+      l.acceptRawString()
     case '\n':
       l.nextLine()
     case ' ', '\r', '\t':
@@ -9,13 +9,24 @@ const (
   RIGHT_PAREN
   LEFT_BRACE
   RIGHT_BRACE
+  LEFT_BRACKET
+  RIGHT_BRACKET
   COMMA
+  COLON
   DOT
   MINUS
   PLUS
   SEMICOLON
   SLASH
   STAR
+  PERCENT
+  TILDE_SLASH
+  STAR_STAR
+  AMPERSAND
+  PIPE
+  CARET
+  TILDE
+  QUESTION
 
   BANG
   EQUAL
@@ -25,27 +36,60 @@ const (
   EQUAL_EQUAL
   LESS_EQUAL
   GREATER_EQUAL
+  LESS_LESS
+  GREATER_GREATER
+  ARROW
+  DOT_DOT
+  DOT_DOT_LESS
+  DOT_DOT_DOT
+  QUESTION_QUESTION
+  QUESTION_DOT
+  PLUS_EQUAL
+  MINUS_EQUAL
+  STAR_EQUAL
+  SLASH_EQUAL
+  PERCENT_EQUAL
+  PLUS_PLUS
+  MINUS_MINUS
 
   IDENTIFIER
   STRING
+  INTERPOLATION
   NUMBER
 
   AND
+  ASYNC
+  AWAIT
+  BREAK
+  CASE
+  CATCH
   CLASS
+  CONST
+  CONTINUE
+  DEFAULT
   ELSE
   FALSE
+  FINALLY
   FUN
   FOR
   IF
+  IMPORT
+  IN
+  MATCH
   NIL
   OR
   PRINT
   RETURN
+  SELECT
+  SPAWN
   SUPER
   THIS
+  THROW
   TRUE
+  TRY
   VAR
   WHILE
+  YIELD
 
   EOF
 )
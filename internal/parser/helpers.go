@@ -10,6 +10,16 @@ func (p *Parser) peek() *lexer.Token {
 	return p.tokens[p.current]
 }
 
+// peekAt returns the token offset positions after the current one, or EOF
+// when the lookahead runs past the end.
+func (p *Parser) peekAt(offset int) *lexer.Token {
+	if p.current+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.current+offset]
+}
+
 func (p *Parser) eof() bool {
 	return p.peek().TokenType == lexer.EOF
 }
@@ -54,11 +64,34 @@ func (p *Parser) acceptToken(tokenType lexer.TokenType, message string) *lexer.T
 	return nil
 }
 
+// acceptContextual consumes an identifier used as a keyword in one position
+// only, such as 'as' in imports, so it stays usable as a name elsewhere.
+func (p *Parser) acceptContextual(lexeme string, message string) *lexer.Token {
+	if p.check(lexer.IDENTIFIER) && p.peek().Lexeme == lexeme {
+		return p.advance()
+	}
+
+	p.parseError(message)
+	return nil
+}
+
+// propertyName consumes the name after a dot. Keywords are allowed there,
+// since nothing else can follow a dot, so that built-in methods such as a
+// promise's catch can be called.
+func (p *Parser) propertyName(message string) *lexer.Token {
+	if lexer.IsKeyword(p.peek().Lexeme) {
+		return p.advance()
+	}
+
+	return p.acceptToken(lexer.IDENTIFIER, message)
+}
+
 func (p *Parser) parseError(message string) {
 	p.HasError = true
 	panic(message)
 }
 
 func (p *Parser) error(token *lexer.Token, err error) {
+	p.HasError = true
 	fmt.Printf("[line: %d] Error: %s\n", token.Line, err.Error())
 }
@@ -14,6 +14,10 @@ type Parser struct {
 
 	current  int
 	HasError bool
+
+	// yields records whether the body of the function being parsed
+	// contains a yield statement.
+	yields bool
 }
 
 func NewParser(tokens []*lexer.Token) *Parser {
@@ -36,12 +40,26 @@ func (p *Parser) declaration() ast.Stmt {
 	if p.match(lexer.VAR) {
 		return p.varDeclaration()
 	}
-	if p.match(lexer.FUN) {
+	if p.match(lexer.CONST) {
+		return p.constDeclaration()
+	}
+	if p.check(lexer.FUN) && p.peekAt(1).TokenType == lexer.IDENTIFIER {
+		p.advance()
 		return p.function("function")
 	}
+	if p.check(lexer.ASYNC) && p.peekAt(1).TokenType == lexer.FUN && p.peekAt(2).TokenType == lexer.IDENTIFIER {
+		p.advance()
+		p.advance()
+		declaration := p.function("function").(ast.Function)
+		declaration.Async = true
+		return declaration
+	}
 	if p.match(lexer.CLASS) {
 		return p.classDeclaration()
 	}
+	if p.match(lexer.IMPORT) {
+		return p.importDeclaration()
+	}
 
 	return p.statement()
 }
@@ -58,44 +76,193 @@ func (p *Parser) varDeclaration() ast.Stmt {
 	return ast.Var{Name: *name, Initializer: initializer}
 }
 
+func (p *Parser) constDeclaration() ast.Stmt {
+	name := p.acceptToken(lexer.IDENTIFIER, "Expect constant name")
+	p.acceptToken(lexer.EQUAL, "Expect '=' after constant name, constants must be initialized")
+	initializer := p.expression()
+	p.acceptToken(lexer.SEMICOLON, "Expect ; after constant declaration")
+
+	return ast.Var{Name: *name, Initializer: initializer, Const: true}
+}
+
 func (p *Parser) function(kind string) ast.Stmt {
 	name := p.acceptToken(lexer.IDENTIFIER, "Expect "+kind+" name")
 
 	p.acceptToken(lexer.LEFT_PAREN, "Expect ( after "+kind+" name")
-	var parameters []lexer.Token
-	if !p.check(lexer.RIGHT_PAREN) {
-		parameters = append(parameters, *p.acceptToken(lexer.IDENTIFIER, "Expect parameter name"))
-	}
+	declaration := p.parameters()
+	declaration.Name = *name
 
-	for p.match(lexer.COMMA) {
-		parameters = append(parameters, *p.acceptToken(lexer.IDENTIFIER, "Expect parameter name"))
-		if len(parameters) > 255 {
+	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before "+kind+" body")
+	declaration.Body, declaration.Generator = p.functionBody()
+
+	return declaration
+}
+
+// functionBody parses the block of a function and reports whether it yields,
+// which makes the function a generator. Yields in nested functions belong to
+// those functions.
+func (p *Parser) functionBody() (ast.Block, bool) {
+	enclosing := p.yields
+	defer func() { p.yields = enclosing }()
+
+	p.yields = false
+	body := p.block()
+
+	return body, p.yields
+}
+
+// parameters parses a parameter list up to the closing parenthesis and
+// returns a declaration with everything but the name and body filled in.
+// Parameters with defaults come after the required ones, and a rest
+// parameter, written '...name', comes last.
+func (p *Parser) parameters() ast.Function {
+	var declaration ast.Function
+
+	for !p.check(lexer.RIGHT_PAREN) && !p.eof() {
+		if p.match(lexer.DOT_DOT_DOT) {
+			declaration.Rest = p.acceptToken(lexer.IDENTIFIER, "Expect rest parameter name after '...'")
+			if p.match(lexer.COMMA) && !p.check(lexer.RIGHT_PAREN) {
+				p.parseError("Rest parameter must be the last parameter")
+			}
+			break
+		}
+
+		name := p.acceptToken(lexer.IDENTIFIER, "Expect parameter name")
+
+		var value ast.Expr
+		if p.match(lexer.EQUAL) {
+			value = p.expression()
+		} else if len(declaration.Defaults) > 0 && declaration.Defaults[len(declaration.Defaults)-1] != nil {
+			p.error(name, errors.New("Parameter without a default value can't follow one with a default"))
+		}
+
+		declaration.Params = append(declaration.Params, *name)
+		declaration.Defaults = append(declaration.Defaults, value)
+		if len(declaration.Params) > 255 {
 			p.error(p.peek(), errors.New("Can't have more than 255 parameters"))
 		}
 
-		if p.check(lexer.RIGHT_PAREN) {
+		if !p.match(lexer.COMMA) {
 			break
 		}
 	}
-	p.acceptToken(lexer.RIGHT_PAREN, "Expect ')' after arguments")
+	p.acceptToken(lexer.RIGHT_PAREN, "Expect ')' after parameters")
 
-	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before "+kind+" body")
-	body := p.block()
+	return declaration
+}
+
+func (p *Parser) lambda() ast.Expr {
+	keyword := p.previous()
+
+	p.acceptToken(lexer.LEFT_PAREN, "Expect ( after 'fun'")
+	declaration := p.parameters()
+	declaration.Name = *keyword
+
+	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before function body")
+	declaration.Body, declaration.Generator = p.functionBody()
 
-	return ast.Function{Name: *name, Params: parameters, Body: ast.Block{Statements: body.Statements}}
+	return ast.Lambda{Declaration: declaration}
+}
+
+// isArrowFunction reports whether the upcoming tokens are the parameter
+// list of an arrow function: either a single identifier or a parenthesized
+// identifier list, followed by '=>'.
+func (p *Parser) isArrowFunction() bool {
+	if p.check(lexer.IDENTIFIER) {
+		return p.peekAt(1).TokenType == lexer.ARROW
+	}
+	if !p.check(lexer.LEFT_PAREN) {
+		return false
+	}
+
+	// Parameters can have default values, so look past the matching
+	// parenthesis rather than trying to recognize a parameter list.
+	depth := 0
+	for offset := 0; ; offset++ {
+		switch p.peekAt(offset).TokenType {
+		case lexer.LEFT_PAREN:
+			depth++
+		case lexer.RIGHT_PAREN:
+			depth--
+			if depth == 0 {
+				return p.peekAt(offset+1).TokenType == lexer.ARROW
+			}
+		case lexer.EOF:
+			return false
+		}
+	}
+}
+
+func (p *Parser) arrowFunction() ast.Expr {
+	var declaration ast.Function
+	if p.match(lexer.IDENTIFIER) {
+		declaration.Params = []lexer.Token{*p.previous()}
+		declaration.Defaults = []ast.Expr{nil}
+	} else {
+		p.acceptToken(lexer.LEFT_PAREN, "Expect ( before parameters")
+		declaration = p.parameters()
+	}
+	arrow := p.acceptToken(lexer.ARROW, "Expect '=>' after parameters")
+	declaration.Name = *arrow
+
+	if p.match(lexer.LEFT_BRACE) {
+		declaration.Body, declaration.Generator = p.functionBody()
+	} else {
+		value := p.assignment()
+		declaration.Body = ast.Block{Statements: []ast.Stmt{ast.Return{Keyword: *arrow, Value: value}}}
+	}
+
+	return ast.Lambda{Declaration: declaration}
 }
 
 func (p *Parser) classDeclaration() ast.Stmt {
 	name := p.acceptToken(lexer.IDENTIFIER, "Expect class name")
+
+	var superclass ast.Expr
+	if p.match(lexer.LESS) {
+		p.acceptToken(lexer.IDENTIFIER, "Expect superclass name")
+		superclass = ast.Variable{Name: *p.previous()}
+	}
+
 	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before class body")
 
 	var methods []ast.Function
 	for !p.check(lexer.RIGHT_BRACE) && !p.eof() {
-		methods = append(methods, p.function("method").(ast.Function))
+		async := p.match(lexer.ASYNC)
+		method := p.function("method").(ast.Function)
+		method.Async = async
+		methods = append(methods, method)
 	}
 	p.acceptToken(lexer.RIGHT_BRACE, "Expect '}' after class body")
 
-	return ast.Class{Name: *name, Methods: methods}
+	return ast.Class{Name: *name, Superclass: superclass, Methods: methods}
+}
+
+func (p *Parser) importDeclaration() ast.Stmt {
+	keyword := p.previous()
+
+	var names []lexer.Token
+	if p.match(lexer.LEFT_BRACE) {
+		for {
+			names = append(names, *p.acceptToken(lexer.IDENTIFIER, "Expect imported name"))
+			if !p.match(lexer.COMMA) || p.check(lexer.RIGHT_BRACE) {
+				break
+			}
+		}
+		p.acceptToken(lexer.RIGHT_BRACE, "Expect '}' after imported names")
+		p.acceptContextual("from", "Expect 'from' after imported names")
+	}
+
+	path := p.acceptToken(lexer.STRING, "Expect module path")
+
+	var alias *lexer.Token
+	if names == nil && p.check(lexer.IDENTIFIER) {
+		p.acceptContextual("as", "Expect 'as' after module path")
+		alias = p.acceptToken(lexer.IDENTIFIER, "Expect module name after 'as'")
+	}
+	p.acceptToken(lexer.SEMICOLON, "Expect ';' after import")
+
+	return ast.Import{Keyword: *keyword, Path: *path, Alias: alias, Names: names}
 }
 
 func (p *Parser) statement() ast.Stmt {
@@ -117,6 +284,34 @@ func (p *Parser) statement() ast.Stmt {
 	if p.match(lexer.RETURN) {
 		return p.returnStatement()
 	}
+	if p.match(lexer.THROW) {
+		return p.throwStatement()
+	}
+	if p.match(lexer.YIELD) {
+		return p.yieldStatement()
+	}
+	if p.match(lexer.TRY) {
+		return p.tryStatement()
+	}
+	if p.match(lexer.MATCH) {
+		return p.matchStatement()
+	}
+	if p.match(lexer.SPAWN) {
+		return p.spawnStatement()
+	}
+	if p.match(lexer.SELECT) {
+		return p.selectStatement()
+	}
+	if p.match(lexer.BREAK) {
+		keyword := p.previous()
+		p.acceptToken(lexer.SEMICOLON, "Expect ';' after 'break'")
+		return ast.Break{Keyword: *keyword}
+	}
+	if p.match(lexer.CONTINUE) {
+		keyword := p.previous()
+		p.acceptToken(lexer.SEMICOLON, "Expect ';' after 'continue'")
+		return ast.Continue{Keyword: *keyword}
+	}
 
 	return p.expressionStatement()
 }
@@ -174,12 +369,16 @@ func (p *Parser) whileStatement() ast.Stmt {
 func (p *Parser) forStatement() ast.Stmt {
 	p.acceptToken(lexer.LEFT_PAREN, "Expect ( after 'for'")
 
+	if p.check(lexer.VAR) && p.peekAt(2).TokenType == lexer.IN {
+		return p.forInStatement()
+	}
+
 	var initializer ast.Stmt
 	if p.match(lexer.SEMICOLON) {
 		initializer = nil
 	} else if p.match(lexer.VAR) {
 		initializer = p.varDeclaration()
-	} else if p.match(lexer.EQUAL) {
+	} else {
 		initializer = p.expressionStatement()
 	}
 
@@ -196,13 +395,10 @@ func (p *Parser) forStatement() ast.Stmt {
 	p.acceptToken(lexer.RIGHT_PAREN, "Expect ) after for clauses")
 
 	body := p.statement()
-	if increment != nil {
-		body = ast.Block{Statements: []ast.Stmt{body, ast.Expression{Expression: increment}}}
-	}
 	if condition == nil {
 		condition = ast.Literal{Value: true}
 	}
-	body = ast.While{Condition: condition, Body: body}
+	body = ast.While{Condition: condition, Body: body, Increment: increment}
 
 	if initializer != nil {
 		body = ast.Block{Statements: []ast.Stmt{initializer, body}}
@@ -211,6 +407,18 @@ func (p *Parser) forStatement() ast.Stmt {
 	return body
 }
 
+func (p *Parser) forInStatement() ast.Stmt {
+	p.acceptToken(lexer.VAR, "Expect 'var' in for-in loop")
+	name := p.acceptToken(lexer.IDENTIFIER, "Expect variable name")
+	in := p.acceptToken(lexer.IN, "Expect 'in' after loop variable")
+	iterable := p.expression()
+	p.acceptToken(lexer.RIGHT_PAREN, "Expect ) after for clauses")
+
+	body := p.statement()
+
+	return ast.ForIn{Name: *name, In: *in, Iterable: iterable, Body: body}
+}
+
 func (p *Parser) returnStatement() ast.Stmt {
 	keyword := p.previous()
 
@@ -223,24 +431,300 @@ func (p *Parser) returnStatement() ast.Stmt {
 	return ast.Return{Keyword: *keyword, Value: value}
 }
 
+func (p *Parser) yieldStatement() ast.Stmt {
+	keyword := p.previous()
+	p.yields = true
+
+	var value ast.Expr
+	if !p.check(lexer.SEMICOLON) {
+		value = p.expression()
+	}
+	p.acceptToken(lexer.SEMICOLON, "Expect ';' after yield value")
+
+	return ast.Yield{Keyword: *keyword, Value: value}
+}
+
+func (p *Parser) throwStatement() ast.Stmt {
+	keyword := p.previous()
+	value := p.expression()
+	p.acceptToken(lexer.SEMICOLON, "Expect ';' after thrown value")
+
+	return ast.Throw{Keyword: *keyword, Value: value}
+}
+
+func (p *Parser) tryStatement() ast.Stmt {
+	keyword := p.previous()
+
+	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' after 'try'")
+	body := p.block()
+
+	statement := ast.Try{Body: body}
+	if p.match(lexer.CATCH) {
+		p.acceptToken(lexer.LEFT_PAREN, "Expect ( after 'catch'")
+		statement.Name = *p.acceptToken(lexer.IDENTIFIER, "Expect exception variable name")
+		p.acceptToken(lexer.RIGHT_PAREN, "Expect ) after exception variable")
+
+		p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before catch body")
+		statement.Catch = p.block()
+	}
+	if p.match(lexer.FINALLY) {
+		p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before finally body")
+		statement.Finally = p.block()
+	}
+
+	if statement.Catch == nil && statement.Finally == nil {
+		p.error(keyword, errors.New("Expect 'catch' or 'finally' after try block"))
+	}
+
+	return statement
+}
+
+func (p *Parser) matchStatement() ast.Stmt {
+	keyword := p.previous()
+	p.acceptToken(lexer.LEFT_PAREN, "Expect '(' after 'match'")
+	value := p.expression()
+	p.acceptToken(lexer.RIGHT_PAREN, "Expect ')' after match value")
+	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' before match arms")
+
+	var arms []ast.MatchArm
+	for !p.check(lexer.RIGHT_BRACE) && !p.eof() {
+		arms = append(arms, p.matchArm())
+	}
+	p.acceptToken(lexer.RIGHT_BRACE, "Expect '}' after match arms")
+
+	return ast.Match{Keyword: *keyword, Value: value, Arms: arms}
+}
+
+func (p *Parser) matchArm() ast.MatchArm {
+	if p.match(lexer.DEFAULT) {
+		keyword := p.previous()
+		p.acceptToken(lexer.ARROW, "Expect '=>' after 'default'")
+		return ast.MatchArm{Keyword: *keyword, Body: p.statement()}
+	}
+
+	keyword := p.acceptToken(lexer.CASE, "Expect 'case' or 'default'")
+	patterns := []ast.Pattern{p.pattern()}
+	for p.match(lexer.COMMA) {
+		patterns = append(patterns, p.pattern())
+	}
+
+	// The guard is parsed below assignment so that 'if ok =>' isn't taken
+	// for an arrow function.
+	var guard ast.Expr
+	if p.match(lexer.IF) {
+		guard = p.conditional()
+	}
+	p.acceptToken(lexer.ARROW, "Expect '=>' after pattern")
+
+	return ast.MatchArm{Keyword: *keyword, Patterns: patterns, Guard: guard, Body: p.statement()}
+}
+
+func (p *Parser) spawnStatement() ast.Stmt {
+	keyword := p.previous()
+
+	call, ok := p.call().(ast.Call)
+	if !ok {
+		p.parseError("Expect a call after 'spawn'")
+	}
+	p.acceptToken(lexer.SEMICOLON, "Expect ';' after spawned call")
+
+	return ast.Spawn{Keyword: *keyword, Call: call}
+}
+
+func (p *Parser) selectStatement() ast.Stmt {
+	keyword := p.previous()
+	p.acceptToken(lexer.LEFT_BRACE, "Expect '{' after 'select'")
+
+	var arms []ast.SelectArm
+	for !p.check(lexer.RIGHT_BRACE) && !p.eof() {
+		arms = append(arms, p.selectArm())
+	}
+	p.acceptToken(lexer.RIGHT_BRACE, "Expect '}' after select arms")
+
+	return ast.Select{Keyword: *keyword, Arms: arms}
+}
+
+func (p *Parser) selectArm() ast.SelectArm {
+	if p.match(lexer.DEFAULT) {
+		keyword := p.previous()
+		p.acceptToken(lexer.ARROW, "Expect '=>' after 'default'")
+		return ast.SelectArm{Keyword: *keyword, Body: p.statement()}
+	}
+
+	keyword := p.acceptToken(lexer.CASE, "Expect 'case' or 'default'")
+	arm := ast.SelectArm{Keyword: *keyword}
+	if p.match(lexer.VAR) {
+		arm.Name = p.acceptToken(lexer.IDENTIFIER, "Expect variable name")
+		p.acceptToken(lexer.EQUAL, "Expect '=' after variable name")
+	}
+
+	// Each arm is a send or receive method call on the channel, such as
+	// 'ch.send(value)', taken apart so that select can wait on it.
+	call, ok := p.call().(ast.Call)
+	get, isGet := call.Callee.(ast.Get)
+	if !ok || len(call.Names) > 0 || !isGet {
+		p.parseError("Expect channel send or receive after 'case'")
+	}
+	arm.Channel, arm.Operation = get.Object, get.Name
+
+	switch {
+	case get.Name.Lexeme == "send" && len(call.Arguments) == 1 && arm.Name == nil:
+		arm.Value = call.Arguments[0]
+	case get.Name.Lexeme == "receive" && len(call.Arguments) == 0:
+	case get.Name.Lexeme == "send" && arm.Name != nil:
+		p.error(&get.Name, errors.New("Can't bind the result of a send"))
+	default:
+		p.parseError("Expect channel send or receive after 'case'")
+	}
+	p.acceptToken(lexer.ARROW, "Expect '=>' after channel operation")
+
+	arm.Body = p.statement()
+	return arm
+}
+
+func (p *Parser) pattern() ast.Pattern {
+	if p.match(lexer.NUMBER, lexer.STRING) {
+		return ast.LiteralPattern{Token: *p.previous(), Value: p.previous().Literal}
+	}
+	if p.match(lexer.TRUE) {
+		return ast.LiteralPattern{Token: *p.previous(), Value: true}
+	}
+	if p.match(lexer.FALSE) {
+		return ast.LiteralPattern{Token: *p.previous(), Value: false}
+	}
+	if p.match(lexer.NIL) {
+		return ast.LiteralPattern{Token: *p.previous(), Value: nil}
+	}
+	if p.match(lexer.MINUS) {
+		minus := p.previous()
+		number := p.acceptToken(lexer.NUMBER, "Expect number after '-' in pattern")
+
+		token := *number
+		token.Lexeme = minus.Lexeme + number.Lexeme
+		switch value := number.Literal.(type) {
+		case int64:
+			return ast.LiteralPattern{Token: token, Value: -value}
+		case float64:
+			return ast.LiteralPattern{Token: token, Value: -value}
+		}
+	}
+	if p.match(lexer.IDENTIFIER) {
+		if p.previous().Lexeme == "_" {
+			return ast.WildcardPattern{Underscore: *p.previous()}
+		}
+		return ast.BindingPattern{Name: *p.previous()}
+	}
+	if p.match(lexer.LEFT_BRACKET) {
+		bracket := p.previous()
+
+		var elements []ast.Pattern
+		for !p.check(lexer.RIGHT_BRACKET) && !p.eof() {
+			elements = append(elements, p.pattern())
+			if !p.match(lexer.COMMA) {
+				break
+			}
+		}
+		p.acceptToken(lexer.RIGHT_BRACKET, "Expect ']' after list pattern")
+
+		return ast.ListPattern{Bracket: *bracket, Elements: elements}
+	}
+
+	p.parseError("Expect pattern")
+	return nil
+}
+
 func (p *Parser) expression() ast.Expr {
 	return p.assignment()
 }
 
 func (p *Parser) assignment() ast.Expr {
-	expr := p.or()
+	if p.isArrowFunction() {
+		return p.arrowFunction()
+	}
+
+	expr := p.conditional()
 
 	if p.match(lexer.EQUAL) {
 		equals := p.previous()
-		value := p.equality()
+		value := p.assignment()
+
+		if !isAssignable(expr) {
+			p.error(equals, errors.New("Invalid assignment target"))
+			return expr
+		}
 
-		switch expr.(type) {
+		switch expr := expr.(type) {
 		case ast.Variable:
-			name := expr.(ast.Variable).Name
-			return ast.Assign{Name: name, Value: value}
+			return ast.Assign{Name: expr.Name, Value: value}
+		case ast.Get:
+			return ast.Set{Object: expr.Object, Name: expr.Name, Value: value}
+		case ast.Index:
+			return ast.SetIndex{Object: expr.Object, Bracket: expr.Bracket, Index: expr.Index, Value: value}
 		}
+	} else if p.match(lexer.PLUS_EQUAL, lexer.MINUS_EQUAL, lexer.STAR_EQUAL, lexer.SLASH_EQUAL, lexer.PERCENT_EQUAL) {
+		operator := p.previous()
+		value := p.assignment()
+
+		return p.update(expr, *operator, value, false)
+	}
 
-		p.error(equals, errors.New("Invalid assignment target"))
+	return expr
+}
+
+// compoundOperators maps the operators that update a target in place to the
+// binary operator they apply.
+var compoundOperators = map[lexer.TokenType]lexer.TokenType{
+	lexer.PLUS_EQUAL:    lexer.PLUS,
+	lexer.MINUS_EQUAL:   lexer.MINUS,
+	lexer.STAR_EQUAL:    lexer.STAR,
+	lexer.SLASH_EQUAL:   lexer.SLASH,
+	lexer.PERCENT_EQUAL: lexer.PERCENT,
+	lexer.PLUS_PLUS:     lexer.PLUS,
+	lexer.MINUS_MINUS:   lexer.MINUS,
+}
+
+func (p *Parser) update(target ast.Expr, operator lexer.Token, value ast.Expr, postfix bool) ast.Expr {
+	if !isAssignable(target) {
+		p.error(&operator, errors.New("Invalid assignment target"))
+		return target
+	}
+
+	// The lexeme is kept so errors still show the operator as written.
+	operator.TokenType = compoundOperators[operator.TokenType]
+
+	return ast.Update{Target: target, Operator: operator, Value: value, Postfix: postfix}
+}
+
+func isAssignable(expr ast.Expr) bool {
+	switch expr.(type) {
+	case ast.Variable, ast.Get, ast.Index:
+		return true
+	}
+
+	return false
+}
+
+func (p *Parser) conditional() ast.Expr {
+	expr := p.coalesce()
+
+	if p.match(lexer.QUESTION) {
+		question := p.previous()
+		thenBranch := p.assignment()
+		p.acceptToken(lexer.COLON, "Expect ':' after then branch of conditional expression")
+		elseBranch := p.assignment()
+		return ast.Conditional{Condition: expr, Question: *question, Then: thenBranch, Else: elseBranch}
+	}
+
+	return expr
+}
+
+func (p *Parser) coalesce() ast.Expr {
+	expr := p.or()
+
+	for p.match(lexer.QUESTION_QUESTION) {
+		operator := p.previous()
+		right := p.or()
+		expr = ast.Logical{Left: expr, Operator: *operator, Right: right}
 	}
 
 	return expr
@@ -284,9 +768,82 @@ func (p *Parser) equality() ast.Expr {
 }
 
 func (p *Parser) comprasion() ast.Expr {
+	expr := p.rangeExpression()
+
+	for p.match(lexer.GREATER, lexer.GREATER_EQUAL, lexer.LESS, lexer.LESS_EQUAL, lexer.IN) {
+		operator := p.previous()
+		right := p.rangeExpression()
+
+		expr = ast.Binary{Left: expr, Operator: *operator, Right: right}
+	}
+
+	return expr
+}
+
+// rangeExpression parses a..b and a..<b, optionally followed by 'step n'.
+// 'step' is only a keyword here, so it stays usable as a name elsewhere.
+func (p *Parser) rangeExpression() ast.Expr {
+	expr := p.bitwiseOr()
+
+	if p.match(lexer.DOT_DOT, lexer.DOT_DOT_LESS) {
+		operator := p.previous()
+		end := p.bitwiseOr()
+
+		var step ast.Expr
+		if p.check(lexer.IDENTIFIER) && p.peek().Lexeme == "step" {
+			p.advance()
+			step = p.bitwiseOr()
+		}
+
+		expr = ast.Range{Start: expr, Operator: *operator, End: end, Step: step}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitwiseOr() ast.Expr {
+	expr := p.bitwiseXor()
+
+	for p.match(lexer.PIPE) {
+		operator := p.previous()
+		right := p.bitwiseXor()
+
+		expr = ast.Binary{Left: expr, Operator: *operator, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitwiseXor() ast.Expr {
+	expr := p.bitwiseAnd()
+
+	for p.match(lexer.CARET) {
+		operator := p.previous()
+		right := p.bitwiseAnd()
+
+		expr = ast.Binary{Left: expr, Operator: *operator, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitwiseAnd() ast.Expr {
+	expr := p.shift()
+
+	for p.match(lexer.AMPERSAND) {
+		operator := p.previous()
+		right := p.shift()
+
+		expr = ast.Binary{Left: expr, Operator: *operator, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) shift() ast.Expr {
 	expr := p.term()
 
-	for p.match(lexer.GREATER, lexer.GREATER_EQUAL, lexer.LESS, lexer.LESS_EQUAL) {
+	for p.match(lexer.LESS_LESS, lexer.GREATER_GREATER) {
 		operator := p.previous()
 		right := p.term()
 
@@ -312,7 +869,7 @@ func (p *Parser) term() ast.Expr {
 func (p *Parser) factor() ast.Expr {
 	expr := p.unary()
 
-	for p.match(lexer.STAR, lexer.SLASH) {
+	for p.match(lexer.STAR, lexer.SLASH, lexer.PERCENT, lexer.TILDE_SLASH) {
 		operator := p.previous()
 		right := p.unary()
 
@@ -323,51 +880,128 @@ func (p *Parser) factor() ast.Expr {
 }
 
 func (p *Parser) unary() ast.Expr {
-	if p.match(lexer.BANG, lexer.MINUS) {
+	if p.match(lexer.BANG, lexer.MINUS, lexer.TILDE) {
 		operator := p.previous()
 		right := p.unary()
 
 		return ast.Unary{Operator: *operator, Right: right}
 	}
+	if p.match(lexer.PLUS_PLUS, lexer.MINUS_MINUS) {
+		operator := p.previous()
+		target := p.unary()
+
+		return p.update(target, *operator, ast.Literal{Value: int64(1)}, false)
+	}
+	if p.match(lexer.AWAIT) {
+		keyword := p.previous()
+		value := p.unary()
+
+		return ast.Await{Keyword: *keyword, Value: value}
+	}
+
+	return p.exponent()
+}
+
+// exponent binds tighter than a unary operator on its left, so -2 ** 2 is
+// -(2 ** 2), and is right-associative: 2 ** 3 ** 2 is 2 ** (3 ** 2).
+func (p *Parser) exponent() ast.Expr {
+	expr := p.postfix()
+
+	if p.match(lexer.STAR_STAR) {
+		operator := p.previous()
+		right := p.unary()
+
+		expr = ast.Binary{Left: expr, Operator: *operator, Right: right}
+	}
 
-	return p.call()
+	return expr
+}
+
+func (p *Parser) postfix() ast.Expr {
+	expr := p.call()
+
+	if p.match(lexer.PLUS_PLUS, lexer.MINUS_MINUS) {
+		operator := p.previous()
+		return p.update(expr, *operator, ast.Literal{Value: int64(1)}, true)
+	}
+
+	return expr
 }
 
 func (p *Parser) call() ast.Expr {
 	expr := p.primary()
+	optional := false
 
 	for {
 		if p.match(lexer.LEFT_PAREN) {
-			expr = p.finishCall(expr)
+			expr = p.finishCall(expr, false)
+		} else if p.match(lexer.DOT) {
+			name := p.propertyName("Expect property name after '.'")
+			expr = ast.Get{Object: expr, Name: *name}
+		} else if p.match(lexer.LEFT_BRACKET) {
+			expr = p.finishIndex(expr, false)
+		} else if p.match(lexer.QUESTION_DOT) {
+			optional = true
+			if p.match(lexer.LEFT_PAREN) {
+				expr = p.finishCall(expr, true)
+			} else if p.match(lexer.LEFT_BRACKET) {
+				expr = p.finishIndex(expr, true)
+			} else {
+				name := p.propertyName("Expect property name after '?.'")
+				expr = ast.Get{Object: expr, Name: *name, Optional: true}
+			}
 		} else {
 			break
 		}
 	}
 
+	if optional {
+		return ast.OptionalChain{Expr: expr}
+	}
 	return expr
 }
 
-func (p *Parser) finishCall(callee ast.Expr) ast.Expr {
-	var arguments []ast.Expr
+func (p *Parser) finishIndex(object ast.Expr, optional bool) ast.Expr {
+	index := p.expression()
+	bracket := p.acceptToken(lexer.RIGHT_BRACKET, "Expect ']' after index")
 
-	if !p.check(lexer.RIGHT_PAREN) {
-		arguments = append(arguments, p.expression())
-	}
+	return ast.Index{Object: object, Bracket: *bracket, Index: index, Optional: optional}
+}
 
-	for p.match(lexer.COMMA) {
-		arguments = append(arguments, p.expression())
-		if len(arguments) > 255 {
+func (p *Parser) finishCall(callee ast.Expr, optional bool) ast.Expr {
+	var arguments, namedArguments []ast.Expr
+	var names []lexer.Token
+
+	for !p.check(lexer.RIGHT_PAREN) && !p.eof() {
+		if p.check(lexer.IDENTIFIER) && p.peekAt(1).TokenType == lexer.COLON {
+			names = append(names, *p.advance())
+			p.advance()
+			namedArguments = append(namedArguments, p.expression())
+		} else {
+			if len(names) > 0 {
+				p.error(p.peek(), errors.New("Positional arguments can't follow named arguments"))
+			}
+			arguments = append(arguments, p.expression())
+		}
+
+		if len(arguments)+len(names) > 255 {
 			p.error(p.peek(), errors.New("Can't have more than 255 arguments"))
 		}
 
-		if p.check(lexer.RIGHT_PAREN) {
+		if !p.match(lexer.COMMA) {
 			break
 		}
-
 	}
 	paren := p.acceptToken(lexer.RIGHT_PAREN, "Expect ')' after arguments")
 
-	return ast.Call{Callee: callee, Paren: *paren, Arguments: arguments}
+	return ast.Call{
+		Callee:         callee,
+		Paren:          *paren,
+		Arguments:      arguments,
+		Names:          names,
+		NamedArguments: namedArguments,
+		Optional:       optional,
+	}
 }
 
 func (p *Parser) primary() ast.Expr {
@@ -384,6 +1018,34 @@ func (p *Parser) primary() ast.Expr {
 	if p.match(lexer.NUMBER, lexer.STRING) {
 		return ast.Literal{Value: p.previous().Literal}
 	}
+	if p.match(lexer.INTERPOLATION) {
+		return p.interpolation()
+	}
+	if p.match(lexer.FUN) {
+		return p.lambda()
+	}
+	if p.match(lexer.ASYNC) {
+		var lambda ast.Lambda
+		if p.match(lexer.FUN) {
+			lambda = p.lambda().(ast.Lambda)
+		} else if p.isArrowFunction() {
+			lambda = p.arrowFunction().(ast.Lambda)
+		} else {
+			p.parseError("Expect function after 'async'")
+		}
+
+		lambda.Declaration.Async = true
+		return lambda
+	}
+	if p.match(lexer.SUPER) {
+		keyword := p.previous()
+		p.acceptToken(lexer.DOT, "Expect '.' after 'super'")
+		method := p.acceptToken(lexer.IDENTIFIER, "Expect superclass method name")
+		return ast.Super{Keyword: *keyword, Method: *method}
+	}
+	if p.match(lexer.THIS) {
+		return ast.This{Keyword: *p.previous()}
+	}
 	if p.match(lexer.IDENTIFIER) {
 		return ast.Variable{Name: *p.previous()}
 	}
@@ -393,11 +1055,72 @@ func (p *Parser) primary() ast.Expr {
 		p.acceptToken(lexer.RIGHT_PAREN, "Expect ')' after expression")
 		return ast.Grouping{Expr: expr}
 	}
+	if p.match(lexer.LEFT_BRACKET) {
+		return p.list()
+	}
+	if p.match(lexer.LEFT_BRACE) {
+		return p.mapLiteral()
+	}
 
 	p.parseError("Expect expression")
 	return nil
 }
 
+func (p *Parser) interpolation() ast.Expr {
+	var parts []ast.Expr
+	for {
+		parts = append(parts, ast.Literal{Value: p.previous().Literal})
+		parts = append(parts, p.expression())
+
+		if !p.match(lexer.INTERPOLATION) {
+			break
+		}
+	}
+
+	end := p.acceptToken(lexer.STRING, "Expect end of string after interpolated expression")
+	parts = append(parts, ast.Literal{Value: end.Literal})
+
+	return ast.Interpolation{Parts: parts}
+}
+
+func (p *Parser) list() ast.Expr {
+	bracket := p.previous()
+
+	var elements []ast.Expr
+	if !p.check(lexer.RIGHT_BRACKET) {
+		elements = append(elements, p.expression())
+	}
+
+	for p.match(lexer.COMMA) {
+		if p.check(lexer.RIGHT_BRACKET) {
+			break
+		}
+
+		elements = append(elements, p.expression())
+	}
+	p.acceptToken(lexer.RIGHT_BRACKET, "Expect ']' after list elements")
+
+	return ast.List{Bracket: *bracket, Elements: elements}
+}
+
+func (p *Parser) mapLiteral() ast.Expr {
+	brace := p.previous()
+
+	var keys, values []ast.Expr
+	for !p.check(lexer.RIGHT_BRACE) && !p.eof() {
+		keys = append(keys, p.expression())
+		p.acceptToken(lexer.COLON, "Expect ':' after map key")
+		values = append(values, p.expression())
+
+		if !p.match(lexer.COMMA) {
+			break
+		}
+	}
+	p.acceptToken(lexer.RIGHT_BRACE, "Expect '}' after map entries")
+
+	return ast.Map{Brace: *brace, Keys: keys, Values: values}
+}
+
 func (p *Parser) errorRecovery() {
 	if err := recover(); err != nil {
 		p.error(p.peek(), fmt.Errorf("%v", err))
@@ -10,6 +10,10 @@ func (r *Resolver) resolveExpression(expression ast.Expr) {
 		r.resolveAssign(expression.(ast.Assign))
 	case ast.Binary:
 		r.resolveBinary(expression.(ast.Binary))
+	case ast.Conditional:
+		r.resolveConditional(expression.(ast.Conditional))
+	case ast.Range:
+		r.resolveRange(expression.(ast.Range))
 	case ast.Call:
 		r.resolveCall(expression.(ast.Call))
 	case ast.Grouping:
@@ -18,27 +22,54 @@ func (r *Resolver) resolveExpression(expression ast.Expr) {
 		r.resolveLiteral(expression.(ast.Literal))
 	case ast.Unary:
 		r.resolveUnary(expression.(ast.Unary))
+	case ast.Update:
+		r.resolveUpdate(expression.(ast.Update))
 	case ast.Logical:
 		r.resolveLogical(expression.(ast.Logical))
+	case ast.Get:
+		r.resolveGet(expression.(ast.Get))
+	case ast.Set:
+		r.resolveSet(expression.(ast.Set))
+	case ast.This:
+		r.resolveThis(expression.(ast.This))
+	case ast.Super:
+		r.resolveSuper(expression.(ast.Super))
+	case ast.List:
+		r.resolveList(expression.(ast.List))
+	case ast.Map:
+		r.resolveMap(expression.(ast.Map))
+	case ast.Lambda:
+		r.resolveLambda(expression.(ast.Lambda))
+	case ast.Await:
+		r.resolveAwait(expression.(ast.Await))
+	case ast.Interpolation:
+		r.resolveInterpolation(expression.(ast.Interpolation))
+	case ast.OptionalChain:
+		r.resolveOptionalChain(expression.(ast.OptionalChain))
+	case ast.Index:
+		r.resolveIndex(expression.(ast.Index))
+	case ast.SetIndex:
+		r.resolveSetIndex(expression.(ast.SetIndex))
 	}
 }
 
 func (r *Resolver) resolveVariable(expression ast.Variable) {
 	if !r.scopes.IsEmpty() {
-		scope := r.scopes.Peek().(map[string]bool)
+		scope := r.scopes.Peek().(map[string]variable)
 		if v, ok := scope[expression.Name.Lexeme]; ok {
-			if !v {
+			if !v.defined {
 				r.error(expression.Name, "Can't read local variable in its own initializer")
 			}
 		}
 	}
 
-	r.resolveLocal(expression, expression.Name)
+	r.resolveLocal(expression.Name)
 }
 
 func (r *Resolver) resolveAssign(expression ast.Assign) {
 	r.resolveExpression(expression.Value)
-	r.resolveLocal(expression, expression.Name)
+	r.checkAssignable(expression.Name)
+	r.resolveLocal(expression.Name)
 }
 
 func (r *Resolver) resolveBinary(expression ast.Binary) {
@@ -46,12 +77,29 @@ func (r *Resolver) resolveBinary(expression ast.Binary) {
 	r.resolveExpression(expression.Right)
 }
 
+func (r *Resolver) resolveConditional(expression ast.Conditional) {
+	r.resolveExpression(expression.Condition)
+	r.resolveExpression(expression.Then)
+	r.resolveExpression(expression.Else)
+}
+
+func (r *Resolver) resolveRange(expression ast.Range) {
+	r.resolveExpression(expression.Start)
+	r.resolveExpression(expression.End)
+	if expression.Step != nil {
+		r.resolveExpression(expression.Step)
+	}
+}
+
 func (r *Resolver) resolveCall(expression ast.Call) {
 	r.resolveExpression(expression.Callee)
 
 	for _, arg := range expression.Arguments {
 		r.resolveExpression(arg)
 	}
+	for _, arg := range expression.NamedArguments {
+		r.resolveExpression(arg)
+	}
 }
 
 func (r *Resolver) resolveGrouping(expression ast.Grouping) {
@@ -66,7 +114,92 @@ func (r *Resolver) resolveUnary(expression ast.Unary) {
 	r.resolveExpression(expression.Right)
 }
 
+func (r *Resolver) resolveUpdate(expression ast.Update) {
+	r.resolveExpression(expression.Value)
+	if target, ok := expression.Target.(ast.Variable); ok {
+		r.checkAssignable(target.Name)
+	}
+	r.resolveExpression(expression.Target)
+}
+
 func (r *Resolver) resolveLogical(expression ast.Logical) {
 	r.resolveExpression(expression.Left)
 	r.resolveExpression(expression.Right)
 }
+
+func (r *Resolver) resolveGet(expression ast.Get) {
+	r.resolveExpression(expression.Object)
+}
+
+func (r *Resolver) resolveSet(expression ast.Set) {
+	r.resolveExpression(expression.Value)
+	r.resolveExpression(expression.Object)
+}
+
+func (r *Resolver) resolveThis(expression ast.This) {
+	if r.currentClass == NO_CLASS {
+		r.error(expression.Keyword, "Can't use 'this' outside of a class")
+		return
+	}
+
+	r.resolveLocal(expression.Keyword)
+}
+
+func (r *Resolver) resolveSuper(expression ast.Super) {
+	if r.currentClass == NO_CLASS {
+		r.error(expression.Keyword, "Can't use 'super' outside of a class")
+		return
+	}
+	if r.currentClass != SUBCLASS {
+		r.error(expression.Keyword, "Can't use 'super' in a class with no superclass")
+		return
+	}
+
+	r.resolveLocal(expression.Keyword)
+}
+
+func (r *Resolver) resolveList(expression ast.List) {
+	for _, element := range expression.Elements {
+		r.resolveExpression(element)
+	}
+}
+
+func (r *Resolver) resolveMap(expression ast.Map) {
+	for i := range expression.Keys {
+		r.resolveExpression(expression.Keys[i])
+		r.resolveExpression(expression.Values[i])
+	}
+}
+
+func (r *Resolver) resolveLambda(expression ast.Lambda) {
+	r.resolveFunctionBody(expression.Declaration, FUNCTION)
+}
+
+func (r *Resolver) resolveAwait(expression ast.Await) {
+	if !r.inAsync {
+		r.error(expression.Keyword, "Can't use 'await' outside an async function")
+	}
+
+	r.resolveExpression(expression.Value)
+}
+
+func (r *Resolver) resolveInterpolation(expression ast.Interpolation) {
+	for _, part := range expression.Parts {
+		r.resolveExpression(part)
+	}
+}
+
+func (r *Resolver) resolveOptionalChain(expression ast.OptionalChain) {
+	r.resolveExpression(expression.Expr)
+}
+
+func (r *Resolver) resolveIndex(expression ast.Index) {
+	r.resolveExpression(expression.Object)
+	r.resolveExpression(expression.Index)
+}
+
+func (r *Resolver) resolveSetIndex(expression ast.SetIndex) {
+	r.resolveExpression(expression.Value)
+	r.resolveExpression(expression.Object)
+	r.resolveExpression(expression.Index)
+}
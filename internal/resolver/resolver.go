@@ -13,12 +13,35 @@ type FunctionType int
 const (
 	NONE FunctionType = iota
 	FUNCTION
+	METHOD
+	INITIALIZER
 )
 
+type ClassType int
+
+const (
+	NO_CLASS ClassType = iota
+	CLASS
+	SUBCLASS
+)
+
+// variable is what the resolver knows about a name declared in a local scope.
+type variable struct {
+	defined  bool
+	constant bool
+}
+
 type Resolver struct {
 	interpreter     *interpreter.Interpreter
 	scopes          *Stack
 	currentFunction FunctionType
+	currentClass    ClassType
+	inGenerator     bool
+	inAsync         bool
+	loopDepth       int
+	// globalConstants holds the global constants declared so far, which
+	// can't be declared again.
+	globalConstants map[string]bool
 	HasError        bool
 }
 
@@ -27,6 +50,8 @@ func NewResolver(interpreter *interpreter.Interpreter) *Resolver {
 		interpreter:     interpreter,
 		scopes:          NewStack(),
 		currentFunction: NONE,
+		currentClass:    NO_CLASS,
+		globalConstants: make(map[string]bool),
 	}
 }
 
@@ -37,7 +62,7 @@ func (r *Resolver) Resolve(statements []ast.Stmt) {
 }
 
 func (r *Resolver) beginScope() {
-	r.scopes.Push(make(map[string]bool))
+	r.scopes.Push(make(map[string]variable))
 }
 
 func (r *Resolver) endScope() {
@@ -46,15 +71,18 @@ func (r *Resolver) endScope() {
 
 func (r *Resolver) declare(name lexer.Token) {
 	if r.scopes.IsEmpty() {
+		if r.globalConstants[name.Lexeme] {
+			r.error(name, fmt.Sprintf("Can't redeclare constant '%s'", name.Lexeme))
+		}
 		return
 	}
 
-	scope := r.scopes.Peek().(map[string]bool)
+	scope := r.scopes.Peek().(map[string]variable)
 	if _, ok := scope[name.Lexeme]; ok {
 		r.error(name, "Already variable with this name in this scope")
 	}
 
-	scope[name.Lexeme] = false
+	scope[name.Lexeme] = variable{}
 }
 
 func (r *Resolver) define(name lexer.Token) {
@@ -62,15 +90,43 @@ func (r *Resolver) define(name lexer.Token) {
 		return
 	}
 
-	scope := r.scopes.Peek().(map[string]bool)
-	scope[name.Lexeme] = true
+	scope := r.scopes.Peek().(map[string]variable)
+	scope[name.Lexeme] = variable{defined: true}
 }
 
-func (r *Resolver) resolveLocal(expression ast.Expr, name lexer.Token) {
+func (r *Resolver) defineConstant(name lexer.Token) {
+	if r.scopes.IsEmpty() {
+		r.globalConstants[name.Lexeme] = true
+		return
+	}
+
+	scope := r.scopes.Peek().(map[string]variable)
+	scope[name.Lexeme] = variable{defined: true, constant: true}
+}
+
+// checkAssignable reports assignments to constants declared before them in
+// the file. The interpreter checks the rest, such as imported constants.
+func (r *Resolver) checkAssignable(name lexer.Token) {
 	for i := r.scopes.Size() - 1; i >= 0; i-- {
-		scope := r.scopes.Get(i).(map[string]bool)
+		scope := r.scopes.Get(i).(map[string]variable)
+		if v, ok := scope[name.Lexeme]; ok {
+			if v.constant {
+				r.error(name, fmt.Sprintf("Can't assign to constant '%s'", name.Lexeme))
+			}
+			return
+		}
+	}
+
+	if r.globalConstants[name.Lexeme] {
+		r.error(name, fmt.Sprintf("Can't assign to constant '%s'", name.Lexeme))
+	}
+}
+
+func (r *Resolver) resolveLocal(name lexer.Token) {
+	for i := r.scopes.Size() - 1; i >= 0; i-- {
+		scope := r.scopes.Get(i).(map[string]variable)
 		if _, ok := scope[name.Lexeme]; ok {
-			r.interpreter.Resolve(expression, r.scopes.Size()-1-i)
+			r.interpreter.Resolve(name, r.scopes.Size()-1-i)
 			return
 		}
 	}
@@ -80,3 +136,8 @@ func (r *Resolver) error(token lexer.Token, message string) {
 	fmt.Printf("[line: %d] Error: %s\n", token.Line, message)
 	r.HasError = true
 }
+
+// warning reports a likely mistake that doesn't stop the program from running.
+func (r *Resolver) warning(token lexer.Token, message string) {
+	fmt.Printf("[line: %d] Warning: %s\n", token.Line, message)
+}
@@ -16,10 +16,32 @@ func (r *Resolver) resolveStatement(statement ast.Stmt) {
 		r.resolvePrint(statement.(ast.Print))
 	case ast.Return:
 		r.resolveReturn(statement.(ast.Return))
+	case ast.If:
+		r.resolveIf(statement.(ast.If))
 	case ast.While:
 		r.resolveWhile(statement.(ast.While))
+	case ast.Throw:
+		r.resolveThrow(statement.(ast.Throw))
+	case ast.Yield:
+		r.resolveYield(statement.(ast.Yield))
+	case ast.Try:
+		r.resolveTry(statement.(ast.Try))
+	case ast.Match:
+		r.resolveMatch(statement.(ast.Match))
+	case ast.ForIn:
+		r.resolveForIn(statement.(ast.ForIn))
+	case ast.Spawn:
+		r.resolveSpawn(statement.(ast.Spawn))
+	case ast.Select:
+		r.resolveSelect(statement.(ast.Select))
+	case ast.Break:
+		r.resolveBreak(statement.(ast.Break))
+	case ast.Continue:
+		r.resolveContinue(statement.(ast.Continue))
 	case ast.Class:
 		r.resolveClass(statement.(ast.Class))
+	case ast.Import:
+		r.resolveImport(statement.(ast.Import))
 	}
 }
 
@@ -35,24 +57,60 @@ func (r *Resolver) resolveVar(statement ast.Var) {
 		r.resolveExpression(statement.Initializer)
 	}
 
-	r.define(statement.Name)
+	if statement.Const {
+		r.defineConstant(statement.Name)
+	} else {
+		r.define(statement.Name)
+	}
 }
 
 func (r *Resolver) resolveFunction(statement ast.Function) {
 	r.declare(statement.Name)
 	r.define(statement.Name)
 
+	r.resolveFunctionBody(statement, FUNCTION)
+}
+
+func (r *Resolver) resolveFunctionBody(statement ast.Function, functionType FunctionType) {
 	enclosingFunction := r.currentFunction
-	r.currentFunction = FUNCTION
+	r.currentFunction = functionType
+
+	enclosingGenerator := r.inGenerator
+	r.inGenerator = statement.Generator
+
+	enclosingAsync := r.inAsync
+	r.inAsync = statement.Async
+	if statement.Async && statement.Generator {
+		r.error(statement.Name, "Async functions can't yield")
+	}
+	if statement.Async && functionType == INITIALIZER {
+		r.error(statement.Name, "Initializer can't be async")
+	}
+
+	// Loops don't extend into nested functions.
+	enclosingLoopDepth := r.loopDepth
+	r.loopDepth = 0
 
 	r.beginScope()
-	for _, param := range statement.Params {
+	for i, param := range statement.Params {
+		// Defaults are evaluated in the function's scope, where the
+		// parameters before them are already bound.
+		if statement.Defaults[i] != nil {
+			r.resolveExpression(statement.Defaults[i])
+		}
 		r.declare(param)
 		r.define(param)
 	}
+	if statement.Rest != nil {
+		r.declare(*statement.Rest)
+		r.define(*statement.Rest)
+	}
 	r.Resolve(statement.Body.Statements)
 	r.endScope()
 
+	r.loopDepth = enclosingLoopDepth
+	r.inAsync = enclosingAsync
+	r.inGenerator = enclosingGenerator
 	r.currentFunction = enclosingFunction
 }
 
@@ -65,7 +123,7 @@ func (r *Resolver) resolveIf(statement ast.If) {
 	r.resolveStatement(statement.ThenBranch)
 
 	if statement.ElseBranch != nil {
-		r.resolveExpression(statement.ElseBranch)
+		r.resolveStatement(statement.ElseBranch)
 	}
 }
 
@@ -79,16 +137,226 @@ func (r *Resolver) resolveReturn(statement ast.Return) {
 	}
 
 	if statement.Value != nil {
+		if r.currentFunction == INITIALIZER {
+			r.error(statement.Keyword, "Can't return a value from an initializer")
+		}
+		if r.inGenerator {
+			r.error(statement.Keyword, "Can't return a value from a generator")
+		}
+
 		r.resolveExpression(statement.Value)
 	}
 }
 
 func (r *Resolver) resolveWhile(statement ast.While) {
 	r.resolveExpression(statement.Condition)
+
+	r.loopDepth += 1
+	r.resolveStatement(statement.Body)
+	r.loopDepth -= 1
+
+	if statement.Increment != nil {
+		r.resolveExpression(statement.Increment)
+	}
+}
+
+func (r *Resolver) resolveForIn(statement ast.ForIn) {
+	r.resolveExpression(statement.Iterable)
+
+	r.beginScope()
+	r.declare(statement.Name)
+	r.define(statement.Name)
+
+	r.loopDepth += 1
 	r.resolveStatement(statement.Body)
+	r.loopDepth -= 1
+	r.endScope()
+}
+
+func (r *Resolver) resolveSpawn(statement ast.Spawn) {
+	r.resolveExpression(statement.Call)
+}
+
+func (r *Resolver) resolveSelect(statement ast.Select) {
+	hasDefault := false
+	for _, arm := range statement.Arms {
+		if arm.Channel == nil {
+			if hasDefault {
+				r.error(arm.Keyword, "Select can't have more than one default arm")
+			}
+			hasDefault = true
+		} else {
+			r.resolveExpression(arm.Channel)
+		}
+		if arm.Value != nil {
+			r.resolveExpression(arm.Value)
+		}
+
+		r.beginScope()
+		if arm.Name != nil {
+			r.declare(*arm.Name)
+			r.define(*arm.Name)
+		}
+		r.resolveStatement(arm.Body)
+		r.endScope()
+	}
+}
+
+func (r *Resolver) resolveYield(statement ast.Yield) {
+	if r.currentFunction == NONE {
+		r.error(statement.Keyword, "Can't yield from top-level code")
+	}
+	if r.currentFunction == INITIALIZER {
+		r.error(statement.Keyword, "Can't yield from an initializer")
+	}
+
+	if statement.Value != nil {
+		r.resolveExpression(statement.Value)
+	}
+}
+
+func (r *Resolver) resolveThrow(statement ast.Throw) {
+	r.resolveExpression(statement.Value)
+}
+
+func (r *Resolver) resolveTry(statement ast.Try) {
+	r.resolveStatement(statement.Body)
+
+	if statement.Catch != nil {
+		r.beginScope()
+		r.declare(statement.Name)
+		r.define(statement.Name)
+		r.Resolve(statement.Catch.(ast.Block).Statements)
+		r.endScope()
+	}
+
+	if statement.Finally != nil {
+		r.resolveStatement(statement.Finally)
+	}
+}
+
+func (r *Resolver) resolveBreak(statement ast.Break) {
+	if r.loopDepth == 0 {
+		r.error(statement.Keyword, "Can't use 'break' outside of a loop")
+	}
+}
+
+func (r *Resolver) resolveContinue(statement ast.Continue) {
+	if r.loopDepth == 0 {
+		r.error(statement.Keyword, "Can't use 'continue' outside of a loop")
+	}
+}
+
+func (r *Resolver) resolveImport(statement ast.Import) {
+	if statement.Alias != nil {
+		r.declare(*statement.Alias)
+		r.define(*statement.Alias)
+	}
+
+	for _, name := range statement.Names {
+		r.declare(name)
+		r.define(name)
+	}
 }
 
 func (r *Resolver) resolveClass(statement ast.Class) {
+	enclosingClass := r.currentClass
+	r.currentClass = CLASS
+
 	r.declare(statement.Name)
 	r.define(statement.Name)
+
+	if statement.Superclass != nil {
+		superclass := statement.Superclass.(ast.Variable)
+		if superclass.Name.Lexeme == statement.Name.Lexeme {
+			r.error(superclass.Name, "A class can't inherit from itself")
+		}
+
+		r.currentClass = SUBCLASS
+		r.resolveExpression(superclass)
+
+		r.beginScope()
+		r.scopes.Peek().(map[string]variable)["super"] = variable{defined: true}
+	}
+
+	r.beginScope()
+	r.scopes.Peek().(map[string]variable)["this"] = variable{defined: true}
+
+	for _, method := range statement.Methods {
+		functionType := METHOD
+		if method.Name.Lexeme == "init" {
+			functionType = INITIALIZER
+		}
+
+		r.resolveFunctionBody(method, functionType)
+	}
+
+	r.endScope()
+
+	if statement.Superclass != nil {
+		r.endScope()
+	}
+
+	r.currentClass = enclosingClass
+}
+
+// resolveMatch gives every arm its own scope for the names its patterns bind.
+// Since values aren't typed, a match only counts as exhaustive when it has a
+// default or an unguarded arm whose pattern matches anything; arms after it
+// can never run.
+func (r *Resolver) resolveMatch(statement ast.Match) {
+	r.resolveExpression(statement.Value)
+
+	exhaustive := false
+	for _, arm := range statement.Arms {
+		if exhaustive {
+			r.warning(arm.Keyword, "Unreachable match arm")
+		}
+
+		r.beginScope()
+		for _, pattern := range arm.Patterns {
+			r.resolvePattern(pattern, len(arm.Patterns) > 1)
+		}
+		if arm.Guard != nil {
+			r.resolveExpression(arm.Guard)
+		}
+		r.resolveStatement(arm.Body)
+		r.endScope()
+
+		if arm.Guard == nil && (arm.Patterns == nil || anyIrrefutable(arm.Patterns)) {
+			exhaustive = true
+		}
+	}
+
+	if !exhaustive {
+		r.warning(statement.Keyword, "Match is not exhaustive, add a default arm")
+	}
+}
+
+// resolvePattern declares the names a pattern binds. Alternatives can't bind
+// names, as it would depend on which one matched whether they are defined.
+func (r *Resolver) resolvePattern(pattern ast.Pattern, alternative bool) {
+	switch pattern := pattern.(type) {
+	case ast.BindingPattern:
+		if alternative {
+			r.error(pattern.Name, "Can't bind names in a case with several patterns")
+		}
+		r.declare(pattern.Name)
+		r.define(pattern.Name)
+	case ast.ListPattern:
+		for _, element := range pattern.Elements {
+			r.resolvePattern(element, alternative)
+		}
+	}
+}
+
+func anyIrrefutable(patterns []ast.Pattern) bool {
+	for _, pattern := range patterns {
+		switch pattern.(type) {
+		case ast.BindingPattern, ast.WildcardPattern:
+			return true
+		}
+	}
+
+	return false
 }